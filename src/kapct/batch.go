@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// workloadProfile is a named set of requests, limits and replicas, e.g. a T-shirt size,
+// the fields go by the switches of the capacity check.
+type workloadProfile struct {
+	Name     string `json:"name"`
+	CPUReq   string `json:"cpureq"`
+	MemReq   string `json:"memreq"`
+	CPULimit string `json:"cpulimit,omitempty"`
+	MemLimit string `json:"memlimit,omitempty"`
+	Replicas int    `json:"replicas"`
+}
+
+// profileList holds the profiles of a batch file written in YAML or JSON.
+type profileList struct {
+	Profiles []workloadProfile `json:"profiles"`
+}
+
+// batch evaluates many workload profiles against the cluster in one run. The cluster is listed
+// once and every profile gets the number of replicas each worker node fits, along with whether
+// all of its replicas fit and on how many nodes its limits, along with the ones of the replicas
+// placed on them, overcommit.
+func batch(args []string) {
+	var profileFile string
+
+	f := flag.NewFlagSet("batch", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&profileFile, "f", "", "profiles file, YAML with a list of profiles or CSV with a header of name,cpureq,memreq,cpulimit,memlimit,replicas.")
+	thresholds := overcommitFlags(f)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct batch -f profiles.yaml|profiles.csv [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if profileFile == "" {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	profiles, err := readProfiles(profileFile)
+	if err != nil {
+		fmt.Println("There is a problem reading profiles!!")
+		panic(err.Error())
+	}
+
+	w := newTabWriter()
+	workers := getWorkerNodes(newClientSet(*kubeconfig))
+
+	// spinable[i][j] is how many replicas of profile j node i fits.
+	spinable := make([][]int64, len(workers))
+	totals, placed, overcommitted := make([]int64, len(profiles)), make([]int64, len(profiles)), make([]int, len(profiles))
+	for i, n := range workers {
+		spinable[i] = make([]int64, len(profiles))
+		// cordoned nodes take no replicas, so the profiles do not overcommit them either.
+		if n.node.Spec.Unschedulable {
+			continue
+		}
+		remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+		cpuLimit, memoryLimit := nodeLimits(n)
+		threshold := thresholds.forNode(n.node)
+		for j, profile := range profiles {
+			spinable[i][j] = nodeFit(remainingCPUReq, remainingMemoryReq, cpuToInt64(profile.CPUReq), ToBytes(profile.MemReq), freePods)
+			totals[j] += spinable[i][j]
+
+			// the replicas of each profile are placed on the nodes in order, as many as each node fits,
+			// the same way as the capacity check projects the limits of a node.
+			replicas := spinable[i][j]
+			if left := int64(profile.Replicas) - placed[j]; replicas > left {
+				replicas = left
+			}
+			placed[j] += replicas
+			if percentOf(cpuLimit+replicas*cpuToInt64(profile.CPULimit), n.cpuAllocatable) > threshold.cpu || percentOf(memoryLimit+replicas*ToBytes(profile.MemLimit), n.memoryAllocatable) > threshold.memory {
+				overcommitted[j]++
+			}
+		}
+	}
+
+	Columns(w, "\n")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%40s\t\n", "Spinable Pods Per Profile")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%-11s\t", "Nodes")
+	for _, profile := range profiles {
+		Rows(w, "%-5s\t", profile.Name)
+	}
+	Columns(w, "\n")
+	for i, n := range workers {
+		Rows(w, "%2s\t", n.name)
+		for j := range profiles {
+			Rows(w, "%5d\t", spinable[i][j])
+		}
+		Columns(w, "\n")
+	}
+	Columns(w, "\n")
+
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%40s\t\n", "Profile Verdicts")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Profile", "CpuReq", "MemReq", "CpuLimit", "MemLimit", "Replicas", "spinable", "Is Scheduleable?")
+	for j, profile := range profiles {
+		verdict := "False"
+		if totals[j] >= int64(profile.Replicas) {
+			verdict = "True"
+		}
+		Rows(w, "%2s\t%6s\t%6s\t%8s\t%8s\t%8d\t%8d\t%16s\t\n", profile.Name, profile.CPUReq, profile.MemReq, profile.CPULimit, profile.MemLimit, profile.Replicas, totals[j], verdict)
+	}
+	Columns(w, "\n")
+
+	Rows(w, "%s\t%d\t%s\t%d\n", "Number of worker nodes: ", len(workers), "Number of Profiles: ", len(profiles))
+	for j, profile := range profiles {
+		if overcommitted[j] > 0 {
+			Rows(w, " W: %s overcommits CPU/Memory limits on %d nodes!!!\n", profile.Name, overcommitted[j])
+		}
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// nodeLimits returns the CPU and memory limits of the pods on the node.
+func nodeLimits(n *clusterNode) (int64, int64) {
+	cpu, memory := int64(0), int64(0)
+	for i := range n.pods {
+		limits := effectiveResources(&n.pods[i].Spec, limitsOf)
+		cpu, memory = cpu+limits.Cpu().MilliValue(), memory+limits.Memory().Value()
+	}
+	return cpu, memory
+}
+
+// readProfiles reads the profiles file, CSV going by its extension and YAML or JSON otherwise.
+// Left out requests take the defaults of the capacity check, limits the requests and replicas 1.
+func readProfiles(path string) ([]workloadProfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]workloadProfile, 0, 3)
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		if profiles, err = parseProfilesCSV(string(data)); err != nil {
+			return nil, err
+		}
+	} else {
+		list := profileList{}
+		if err := yaml.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		profiles = list.Profiles
+	}
+
+	for i := range profiles {
+		if profiles[i].Name == "" {
+			profiles[i].Name = fmt.Sprintf("profile-%d", i+1)
+		}
+		if profiles[i].CPUReq == "" {
+			profiles[i].CPUReq = "100m"
+		}
+		if profiles[i].MemReq == "" {
+			profiles[i].MemReq = "1G"
+		}
+		if profiles[i].CPULimit == "" {
+			profiles[i].CPULimit = profiles[i].CPUReq
+		}
+		if profiles[i].MemLimit == "" {
+			profiles[i].MemLimit = profiles[i].MemReq
+		}
+		if profiles[i].Replicas < 1 {
+			profiles[i].Replicas = 1
+		}
+	}
+	return profiles, nil
+}
+
+// parseProfilesCSV parses profiles out of CSV, the header row names the columns.
+func parseProfilesCSV(data string) ([]workloadProfile, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("profiles file has no header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	profiles := make([]workloadProfile, 0, len(records)-1)
+	for _, record := range records[1:] {
+		profile := workloadProfile{
+			Name:     field(record, "name"),
+			CPUReq:   field(record, "cpureq"),
+			MemReq:   field(record, "memreq"),
+			CPULimit: field(record, "cpulimit"),
+			MemLimit: field(record, "memlimit"),
+		}
+		if replicas := field(record, "replicas"); replicas != "" {
+			if profile.Replicas, err = strconv.Atoi(replicas); err != nil {
+				return nil, fmt.Errorf("%q is not a valid number of replicas for %s", replicas, profile.Name)
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
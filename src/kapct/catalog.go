@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// nodeTemplate is an entry of the instance catalog, a kind of node the cluster could be scaled up with.
+// A template without a price is left out of the cheapest mix.
+type nodeTemplate struct {
+	Name        string            `json:"name"`
+	Allocatable v1.ResourceList   `json:"allocatable"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Taints      []v1.Taint        `json:"taints,omitempty"`
+	Price       float64           `json:"price"`
+}
+
+// instanceCatalog lists the node templates, it is read from a YAML or JSON file.
+type instanceCatalog struct {
+	Templates []nodeTemplate `json:"templates"`
+}
+
+// scaleUpOption holds how many of the replicas left out a node of the template fits, once its
+// DaemonSet pods took their share, and how many such nodes it takes to fit all of them.
+type scaleUpOption struct {
+	template nodeTemplate
+	dsCPU    int64
+	dsMemory int64
+	dsPods   int64
+	perNode  int64
+	nodes    int64
+}
+
+// readCatalog reads the instance catalog file.
+func readCatalog(path string) (*instanceCatalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &instanceCatalog{}
+	if err := yaml.Unmarshal(data, catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// priced tells if the template has an hourly price.
+func (t nodeTemplate) priced() bool {
+	return t.Price > 0
+}
+
+// node returns a node out of the template, so it can be looked at like the nodes of the cluster.
+func (t nodeTemplate) node() *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: t.Name, Labels: t.Labels},
+		Spec:       v1.NodeSpec{Taints: t.Taints},
+		Status:     v1.NodeStatus{Capacity: t.Allocatable, Allocatable: t.Allocatable},
+	}
+}
+
+// planScaleUp calculates, for every template in the catalog, how many replicas a new node fits
+// once the DaemonSets took their share and how many new nodes the missing replicas need.
+// Templates the pods do not tolerate or select fit none of them.
+func planScaleUp(templates []nodeTemplate, daemonSets []appsv1.DaemonSet, spec *v1.PodSpec, cpuAsk int64, memoryAsk int64, extendedAsk extendedResources, hostPorts []v1.ContainerPort, nodePorts []string, missing int64) []scaleUpOption {
+	options := make([]scaleUpOption, 0, len(templates))
+
+	for _, t := range templates {
+		n := cloneNode(t.node(), t.Name, daemonSets)
+		option := scaleUpOption{template: t, dsPods: int64(len(n.pods))}
+		dsCPU, dsMemory, dsExtended := daemonSetTax(n.pods)
+		option.dsCPU, option.dsMemory = dsCPU, dsMemory
+
+		if schedulableOn(spec, n.node) {
+			remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+			option.perNode = nodeFit(remainingCPUReq, remainingMemoryReq, cpuAsk, memoryAsk, freePods)
+			if extendedFit, _ := extendedSpinable(nodeExtendedAllocatable(n.node.Status.Allocatable), dsExtended, extendedAsk, option.perNode); extendedFit < option.perNode {
+				option.perNode = extendedFit
+			}
+			if portCap := hostPortCap(hostPorts, append(hostPortConflicts(n.pods, hostPorts), nodePorts...), option.perNode); portCap < option.perNode {
+				option.perNode = portCap
+			}
+		}
+		if option.perNode > 0 {
+			option.nodes = (missing + option.perNode - 1) / option.perNode
+		}
+		options = append(options, option)
+	}
+
+	return options
+}
+
+// cheapestMix returns how many nodes of each priced template fit the missing replicas for the
+// lowest hourly price, false if none of them fits any of them.
+func cheapestMix(options []scaleUpOption, missing int64) (map[string]int64, float64, bool) {
+
+	// best is the template with the lowest price per replica, fitting b replicas per node. Among
+	// any b nodes of the other templates, whichever they are, some of them fit a multiple of b
+	// replicas together, k*b say: of the b running sums of their replicas either one is divisible
+	// by b or two leave the same remainder. Those nodes cost no less than the k best nodes fitting
+	// the same replicas, so they can be swapped for them. Hence there is an optimal mix with less
+	// than b nodes of the other templates, fitting less than bound replicas however many of them
+	// there are. The replicas past bound go to best nodes right away, which keeps the search below
+	// as small as the templates are, however many replicas are missing.
+	best, largest := -1, int64(0)
+	for i, option := range options {
+		if option.perNode <= 0 || !option.template.priced() {
+			continue
+		}
+		if best == -1 || option.template.Price/float64(option.perNode) < options[best].template.Price/float64(options[best].perNode) {
+			best = i
+		}
+		if option.perNode > largest {
+			largest = option.perNode
+		}
+	}
+	if best == -1 {
+		return nil, 0, false
+	}
+	bulk := int64(0)
+	if bound := options[best].perNode * largest; missing > bound {
+		bulk = (missing - bound) / options[best].perNode
+		missing -= bulk * options[best].perNode
+	}
+
+	// cost[r] is the lowest price fitting r replicas, choice[r] the template added last for it.
+	cost, choice := make([]float64, missing+1), make([]int, missing+1)
+	for r := int64(1); r <= missing; r++ {
+		cost[r], choice[r] = math.Inf(1), -1
+		for i, option := range options {
+			if option.perNode <= 0 || !option.template.priced() {
+				continue
+			}
+			previous := r - option.perNode
+			if previous < 0 {
+				previous = 0
+			}
+			if cost[previous]+option.template.Price < cost[r] {
+				cost[r], choice[r] = cost[previous]+option.template.Price, i
+			}
+		}
+		if choice[r] == -1 {
+			return nil, 0, false
+		}
+	}
+
+	mix := make(map[string]int64)
+	for r := missing; r > 0; r -= options[choice[r]].perNode {
+		mix[options[choice[r]].template.Name]++
+	}
+	mix[options[best].template.Name] += bulk
+	return mix, cost[missing] + float64(bulk)*options[best].template.Price, true
+}
+
+// printScaleUpPlan prints the nodes of each template, and the cheapest mix of them, the cluster
+// needs to be scaled up with to fit the missing replicas.
+func printScaleUpPlan(p *tabwriter.Writer, options []scaleUpOption, missing int64) {
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Scale-Up Plan")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Template", "DsCpu", "DsMem", "DsPods", "PerNode", "NodesNeeded", "HourlyPrice", "HourlyCost")
+	unpriced := make([]string, 0, 3)
+	for _, option := range options {
+		switch {
+		case !option.template.priced():
+			unpriced = append(unpriced, option.template.Name)
+			if option.perNode > 0 {
+				Rows(p, "%2s\t%5dm\t%5dM\t%6d\t%7d\t%11d\t%11s\t%10s\t\n", option.template.Name, option.dsCPU, option.dsMemory/MEGABYTE, option.dsPods, option.perNode, option.nodes, "-", "-")
+			} else {
+				Rows(p, "%2s\t%5dm\t%5dM\t%6d\t%7d\t%11s\t%11s\t%10s\t\n", option.template.Name, option.dsCPU, option.dsMemory/MEGABYTE, option.dsPods, 0, "-", "-", "-")
+			}
+		case option.perNode > 0:
+			Rows(p, "%2s\t%5dm\t%5dM\t%6d\t%7d\t%11d\t%11.3f\t%10.3f\t\n", option.template.Name, option.dsCPU, option.dsMemory/MEGABYTE, option.dsPods, option.perNode, option.nodes, option.template.Price, float64(option.nodes)*option.template.Price)
+		default:
+			Rows(p, "%2s\t%5dm\t%5dM\t%6d\t%7d\t%11s\t%11.3f\t%10s\t\n", option.template.Name, option.dsCPU, option.dsMemory/MEGABYTE, option.dsPods, 0, "-", option.template.Price, "-")
+		}
+	}
+	Columns(p, "\n")
+
+	Rows(p, "%s\t%d\t%s\t%d\n", "Replicas Left Out: ", missing, "Unpriced Templates: ", len(unpriced))
+	Rows(p, "%s\t%s\t", "Unpriced Templates List: ", VPrint(unpriced))
+
+	mix, cost, ok := cheapestMix(options, missing)
+	if !ok {
+		Rows(p, "%s\t%s\t\n", "Cheapest Mix: ", "None of the priced templates fits the replicas")
+		Columns(p, "\n")
+		return
+	}
+
+	parts := make([]string, 0, len(mix))
+	for _, option := range options {
+		if count := mix[option.template.Name]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d x %s", count, option.template.Name))
+			delete(mix, option.template.Name)
+		}
+	}
+	Rows(p, "%s\t%s\t%s\t%.3f\n", "Cheapest Mix: ", strings.Join(parts, ", "), "Cheapest Mix Hourly Cost: ", cost)
+	Columns(p, "\n")
+}
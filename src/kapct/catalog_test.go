@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// option returns a scale-up option of the template fitting perNode replicas at the price given.
+func option(name string, perNode int64, price float64) scaleUpOption {
+	return scaleUpOption{template: nodeTemplate{Name: name, Price: price}, perNode: perNode}
+}
+
+func TestCheapestMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []scaleUpOption
+		missing int64
+		mix     map[string]int64
+		cost    float64
+		ok      bool
+	}{
+		{
+			name:    "single template",
+			options: []scaleUpOption{option("small", 4, 1)},
+			missing: 10,
+			mix:     map[string]int64{"small": 3},
+			cost:    3,
+			ok:      true,
+		},
+		{
+			name:    "larger node cheaper per replica",
+			options: []scaleUpOption{option("small", 2, 1), option("large", 8, 3)},
+			missing: 16,
+			mix:     map[string]int64{"large": 2},
+			cost:    6,
+			ok:      true,
+		},
+		{
+			name:    "small node tops up the large ones",
+			options: []scaleUpOption{option("small", 2, 1), option("large", 8, 3)},
+			missing: 18,
+			mix:     map[string]int64{"large": 2, "small": 1},
+			cost:    7,
+			ok:      true,
+		},
+		{
+			name:    "three templates of the same size",
+			options: []scaleUpOption{option("a", 3, 3), option("b", 3, 3.5), option("c", 3, 4)},
+			missing: 30,
+			mix:     map[string]int64{"a": 10},
+			cost:    30,
+			ok:      true,
+		},
+		{
+			name:    "unpriced and unfitting templates left out",
+			options: []scaleUpOption{option("free", 100, 0), option("none", 0, 0.5), option("small", 5, 2)},
+			missing: 12,
+			mix:     map[string]int64{"small": 3},
+			cost:    6,
+			ok:      true,
+		},
+		{
+			name:    "nothing priced fits",
+			options: []scaleUpOption{option("free", 100, 0), option("none", 0, 0.5)},
+			missing: 12,
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mix, cost, ok := cheapestMix(tt.options, tt.missing)
+			if ok != tt.ok {
+				t.Fatalf("ok = %t, want %t", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(mix, tt.mix) || math.Abs(cost-tt.cost) > 1e-9 {
+				t.Errorf("mix = %v for %g, want %v for %g", mix, cost, tt.mix, tt.cost)
+			}
+		})
+	}
+}
+
+// bruteForceCost returns the lowest price of the nodes of the options fitting the replicas,
+// trying every number of nodes of each of them.
+func bruteForceCost(options []scaleUpOption, missing int64) float64 {
+	lowest := math.Inf(1)
+	var walk func(i int, replicas int64, cost float64)
+	walk = func(i int, replicas int64, cost float64) {
+		if replicas >= missing {
+			lowest = math.Min(lowest, cost)
+			return
+		}
+		if i == len(options) {
+			return
+		}
+		for count := int64(0); count*options[i].perNode < missing-replicas+options[i].perNode; count++ {
+			walk(i+1, replicas+count*options[i].perNode, cost+float64(count)*options[i].template.Price)
+		}
+	}
+	walk(0, 0, 0)
+	return lowest
+}
+
+func TestCheapestMixAgainstBruteForce(t *testing.T) {
+	catalogs := [][]scaleUpOption{
+		{option("a", 3, 3), option("b", 3, 3.2), option("c", 3, 3.1)},
+		{option("a", 3, 2.9), option("b", 4, 4), option("c", 5, 5)},
+		{option("a", 7, 6), option("b", 5, 4.5), option("c", 2, 1.9)},
+		{option("a", 6, 5), option("b", 4, 3.5)},
+		{option("a", 10, 7), option("b", 3, 2.2), option("c", 1, 0.8)},
+	}
+
+	for c, options := range catalogs {
+		for missing := int64(1); missing <= 60; missing++ {
+			mix, cost, ok := cheapestMix(options, missing)
+			if !ok {
+				t.Fatalf("catalog %d, %d replicas: no mix", c, missing)
+			}
+			if want := bruteForceCost(options, missing); math.Abs(cost-want) > 1e-9 {
+				t.Errorf("catalog %d, %d replicas: mix %v costs %g, want %g", c, missing, mix, cost, want)
+			}
+
+			// the mix fits the replicas and costs what is returned.
+			fitted, priced := int64(0), float64(0)
+			for _, option := range options {
+				fitted += mix[option.template.Name] * option.perNode
+				priced += float64(mix[option.template.Name]) * option.template.Price
+			}
+			if fitted < missing || math.Abs(priced-cost) > 1e-9 {
+				t.Errorf("catalog %d, %d replicas: mix %v fits %d for %g, returned %g", c, missing, mix, fitted, priced, cost)
+			}
+		}
+	}
+}
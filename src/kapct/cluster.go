@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// clusterNode holds a worker node along with the pods running on it, the
+// simulations move pods between these without touching the cluster.
+type clusterNode struct {
+	name              string
+	node              *v1.Node
+	pods              []v1.Pod
+	cpuAllocatable    int64
+	memoryAllocatable int64
+	podAllocatable    int64
+	cpuReq            int64
+	memoryReq         int64
+}
+
+// podMove holds where a pod was moved from and to, to is empty if it found no room.
+type podMove struct {
+	pod  v1.Pod
+	from string
+	to   string
+}
+
+// newClusterNode creates a clusterNode out of the node and the pods running on it.
+func newClusterNode(node *v1.Node, pods []v1.Pod) *clusterNode {
+	n := &clusterNode{
+		name:              shortName(node.Name),
+		node:              node,
+		pods:              make([]v1.Pod, 0, len(pods)),
+		cpuAllocatable:    node.Status.Allocatable.Cpu().MilliValue(),
+		memoryAllocatable: node.Status.Allocatable.Memory().Value(),
+		podAllocatable:    node.Status.Allocatable.Pods().Value(),
+	}
+	for _, p := range pods {
+		n.place(p)
+	}
+	return n
+}
+
+// remaining returns the CPU, memory and pod slots left on the node.
+func (n *clusterNode) remaining() (int64, int64, int64) {
+	return n.cpuAllocatable - n.cpuReq, n.memoryAllocatable - n.memoryReq, n.podAllocatable - int64(len(n.pods))
+}
+
+// fits tells if the pod would fit on the node, going by its requests.
+func (n *clusterNode) fits(p *v1.Pod) bool {
+	cpu, memory := podRequests(p)
+	remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+	return nodeFit(remainingCPUReq, remainingMemoryReq, cpu, memory, freePods) >= 1
+}
+
+// place adds the pod to the node.
+func (n *clusterNode) place(p v1.Pod) {
+	cpu, memory := podRequests(&p)
+	n.cpuReq += cpu
+	n.memoryReq += memory
+	n.pods = append(n.pods, p)
+}
+
+// evict removes the pod from the node.
+func (n *clusterNode) evict(p *v1.Pod) {
+	for i := range n.pods {
+		if podKey(&n.pods[i]) == podKey(p) {
+			cpu, memory := podRequests(p)
+			n.cpuReq -= cpu
+			n.memoryReq -= memory
+			n.pods = append(n.pods[:i], n.pods[i+1:]...)
+			return
+		}
+	}
+}
+
+// getWorkerNodes returns the healthy worker nodes along with the pods running on them.
+func getWorkerNodes(c *k8s.Clientset) []*clusterNode {
+
+	nodes, err := c.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting node list!!")
+		panic(err.Error())
+	}
+
+	// set condition to identify the non terminted pods, based on the Pods Life Cycle
+	fieldSelector, err := fields.ParseSelector("status.phase!=" + "Pending" + ",status.phase!=" + "Succeeded" + ",status.phase!=" + "Failed" + ",status.phase!=" + "Unknown")
+	if err != nil {
+		fmt.Println("There is a problem setting filters!!")
+		panic(err.Error())
+	}
+	pods, err := c.CoreV1().Pods("").List(metav1.ListOptions{FieldSelector: fieldSelector.String()})
+	if err != nil {
+		fmt.Println("There is a problem getting pods!!")
+		panic(err.Error())
+	}
+
+	nodePods := make(map[string][]v1.Pod)
+	for _, p := range pods.Items {
+		nodePods[p.Spec.NodeName] = append(nodePods[p.Spec.NodeName], p)
+	}
+
+	workers := make([]*clusterNode, 0, len(nodes.Items))
+	for n := range nodes.Items {
+		if isHealthy(&nodes.Items[n]) && isWorker(&nodes.Items[n]) {
+			workers = append(workers, newClusterNode(&nodes.Items[n], nodePods[nodes.Items[n].Name]))
+		}
+	}
+	return workers
+}
+
+// findNode returns the node by its name or its short name, nil if there is none.
+func findNode(nodes []*clusterNode, name string) *clusterNode {
+	for _, n := range nodes {
+		if n.node.Name == name || n.name == name || n.name == name+"." {
+			return n
+		}
+	}
+	return nil
+}
+
+// reschedulePods places the pods on the nodes, the largest pod first on the first node it
+// fits and may be scheduled on, going by its node selector, node affinity and tolerations,
+// and returns the moves made. Cordoned nodes take no pods.
+func reschedulePods(pods []podMove, nodes []*clusterNode) []podMove {
+
+	moves := make([]podMove, len(pods))
+	copy(moves, pods)
+	sort.SliceStable(moves, func(i, j int) bool {
+		cpuI, memoryI := podRequests(&moves[i].pod)
+		cpuJ, memoryJ := podRequests(&moves[j].pod)
+		if cpuI != cpuJ {
+			return cpuI > cpuJ
+		}
+		return memoryI > memoryJ
+	})
+
+	for i := range moves {
+		moves[i].to = ""
+		for _, n := range nodes {
+			if !n.node.Spec.Unschedulable && schedulableOn(&moves[i].pod.Spec, n.node) && n.fits(&moves[i].pod) {
+				n.place(moves[i].pod)
+				moves[i].to = n.name
+				break
+			}
+		}
+	}
+	return moves
+}
+
+// cloneNode returns a schedulable copy of the node with the given name, running only the
+// pods of the DaemonSets which would start on it.
+func cloneNode(node *v1.Node, name string, daemonSets []appsv1.DaemonSet) *clusterNode {
+	clone := node.DeepCopy()
+	clone.Name = name
+	clone.Spec.Unschedulable = false
+	// a template may only tell the capacity, the whole of it is allocatable then.
+	if len(clone.Status.Allocatable) == 0 {
+		clone.Status.Allocatable = clone.Status.Capacity
+	}
+	return newClusterNode(clone, daemonSetPods(daemonSets, clone))
+}
+
+// copyNodes returns a copy of the nodes, pods can be placed on the copy without touching the originals.
+func copyNodes(nodes []*clusterNode) []*clusterNode {
+	copies := make([]*clusterNode, 0, len(nodes))
+	for _, n := range nodes {
+		clone := *n
+		clone.pods = append([]v1.Pod{}, n.pods...)
+		copies = append(copies, &clone)
+	}
+	return copies
+}
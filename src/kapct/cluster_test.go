@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestReschedulePods(t *testing.T) {
+	// withNode returns a pod of 1 CPU and 1Gi with the spec changed as given.
+	withNode := func(name string, change func(spec *v1.PodSpec)) v1.Pod {
+		p := testPod("shop", name, "web", resources("1", "1Gi"))
+		change(&p.Spec)
+		return p
+	}
+	required := func(operator v1.NodeSelectorOperator, values ...string) func(spec *v1.PodSpec) {
+		return func(spec *v1.PodSpec) {
+			spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "disk", Operator: operator, Values: values}}}},
+			}}}
+		}
+	}
+
+	tests := []struct {
+		name string
+		pod  v1.Pod
+		to   string
+	}{
+		{name: "no constraints", pod: withNode("plain", func(*v1.PodSpec) {}), to: "worker-1"},
+		{name: "node selector", pod: withNode("selector", func(spec *v1.PodSpec) { spec.NodeSelector = map[string]string{"disk": "ssd"} }), to: "worker-2"},
+		{name: "node selector nothing matches", pod: withNode("nowhere", func(spec *v1.PodSpec) { spec.NodeSelector = map[string]string{"disk": "nvme"} }), to: ""},
+		{name: "required node affinity", pod: withNode("affinity", required(v1.NodeSelectorOpIn, "ssd")), to: "worker-2"},
+		{name: "required node affinity not in", pod: withNode("not-in", required(v1.NodeSelectorOpNotIn, "hdd")), to: "worker-2"},
+		{name: "required node affinity matching a tainted node only", pod: withNode("tainted", required(v1.NodeSelectorOpDoesNotExist)), to: ""},
+		{name: "toleration of the taint", pod: withNode("tolerant", func(spec *v1.PodSpec) {
+			spec.NodeSelector = map[string]string{"pool": "gpu"}
+			spec.Tolerations = []v1.Toleration{{Key: "gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}}
+		}), to: "worker-3"},
+		{name: "taint without toleration", pod: withNode("intolerant", func(spec *v1.PodSpec) { spec.NodeSelector = map[string]string{"pool": "gpu"} }), to: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hdd := workerNode("worker-1", "4", "4Gi")
+			hdd.node.Labels = map[string]string{"disk": "hdd"}
+			ssd := workerNode("worker-2", "4", "4Gi")
+			ssd.node.Labels = map[string]string{"disk": "ssd"}
+			gpu := workerNode("worker-3", "4", "4Gi")
+			gpu.node.Labels = map[string]string{"pool": "gpu"}
+			gpu.node.Spec.Taints = []v1.Taint{{Key: "gpu", Value: "true", Effect: v1.TaintEffectNoSchedule}}
+
+			moves := reschedulePods([]podMove{{pod: tt.pod, from: "worker-0"}}, []*clusterNode{hdd, ssd, gpu})
+			if moves[0].to != tt.to {
+				t.Errorf("moved to %q, want %q", moves[0].to, tt.to)
+			}
+		})
+	}
+}
+
+func TestReschedulePodsRoom(t *testing.T) {
+	full := workerNode("worker-1", "2", "2Gi", testPod("shop", "db-1", "db", resources("2", "2Gi")))
+	cordoned := workerNode("worker-2", "8", "8Gi")
+	cordoned.node.Spec.Unschedulable = true
+	exact := workerNode("worker-3", "2", "2Gi")
+
+	pods := []podMove{
+		{pod: testPod("shop", "web-1", "web", resources("1", "1Gi")), from: "worker-0"},
+		{pod: testPod("shop", "web-2", "web", resources("1", "1Gi")), from: "worker-0"},
+		{pod: testPod("shop", "web-3", "web", resources("1", "1Gi")), from: "worker-0"},
+	}
+	moves := reschedulePods(pods, []*clusterNode{full, cordoned, exact})
+
+	// the first two fill worker-3 exactly, the third finds no room.
+	want := map[string]string{"web-1": "worker-3", "web-2": "worker-3", "web-3": ""}
+	for _, move := range moves {
+		if move.to != want[move.pod.Name] {
+			t.Errorf("%s moved to %q, want %q", move.pod.Name, move.to, want[move.pod.Name])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// consolidate finds the worker nodes which could be removed because their pods can be
+// repacked on the rest, following the scale-down rules of the cluster-autoscaler. The least
+// utilized nodes are tried first and a node is removed only if every pod on it moves.
+func consolidate(args []string) {
+	f := flag.NewFlagSet("consolidate", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct consolidate [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	w := newTabWriter()
+	workers := getWorkerNodes(newClientSet(*kubeconfig))
+
+	left, removed, blocked, moves := planConsolidation(workers)
+
+	// the cordoned nodes take no pods, they count neither as nodes left nor for the capacity left.
+	schedulable, cpuLeft, memoryLeft, cpuAllocatable, memoryAllocatable := 0, int64(0), int64(0), int64(0), int64(0)
+	for _, n := range left {
+		if !n.node.Spec.Unschedulable {
+			remainingCPUReq, remainingMemoryReq, _ := n.remaining()
+			schedulable++
+			cpuLeft, memoryLeft = cpuLeft+remainingCPUReq, memoryLeft+remainingMemoryReq
+			cpuAllocatable, memoryAllocatable = cpuAllocatable+n.cpuAllocatable, memoryAllocatable+n.memoryAllocatable
+		}
+	}
+
+	printMoves(w, "Consolidation Moves", moves)
+	Rows(w, "%s\t%d\t%s\t%d\n", "Number of worker nodes: ", len(workers), "Nodes Left: ", schedulable)
+	Rows(w, "%s\t%d\t\n", "Removable Nodes: ", len(removed))
+	Rows(w, "%s\t%s\t", "Removable Nodes List: ", VPrint(removed))
+	Rows(w, "%s\t%d\t\n", "Blocked Nodes: ", len(blocked))
+	Rows(w, "%s\t%s\t", "Blocked Nodes List: ", VPrint(blocked))
+	Columns(w, "\n")
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "CPU Left After Consolidation: ", cpuLeft, "Memory Left After Consolidation: ", memoryLeft/MEGABYTE)
+	if cpuAllocatable > 0 && memoryAllocatable > 0 {
+		Rows(w, "%s\t%4.2f%%\t%s\t%4.2f%%\n", "CPU Requested After Consolidation: ", float64(cpuAllocatable-cpuLeft)/float64(cpuAllocatable)*100, "Memory Requested After Consolidation: ", float64(memoryAllocatable-memoryLeft)/float64(memoryAllocatable)*100)
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// planConsolidation removes the nodes whose pods all find room on the rest, the least utilized
+// first, and returns the nodes left along with the pods moved onto them, the nodes removed, the
+// ones blocked and why, and the moves made. The nodes given are left as they are.
+func planConsolidation(workers []*clusterNode) ([]*clusterNode, []string, []string, []podMove) {
+	left := copyNodes(workers)
+	candidates := append([]*clusterNode{}, left...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return utilization(candidates[i]) < utilization(candidates[j])
+	})
+
+	removed, blocked, moves := make([]string, 0, 3), make([]string, 0, 3), make([]podMove, 0, 3)
+	for _, candidate := range candidates {
+		if candidate.node.Spec.Unschedulable {
+			continue
+		}
+
+		// DaemonSet and mirror pods go along with the node, the rest has to move.
+		displaced, reason := make([]podMove, 0, len(candidate.pods)), ""
+		for _, p := range candidate.pods {
+			if isDaemonSetPod(&p) || isMirrorPod(&p) {
+				continue
+			}
+			if reason = scaleDownBlocker(&p); reason != "" {
+				break
+			}
+			displaced = append(displaced, podMove{pod: p, from: candidate.name})
+		}
+		if reason != "" {
+			blocked = append(blocked, fmt.Sprintf("%s (%s)", candidate.name, reason))
+			continue
+		}
+
+		// try the moves on a copy of the other nodes, they change only if all the pods find room.
+		others := make([]*clusterNode, 0, len(left)-1)
+		for _, n := range left {
+			if n != candidate {
+				others = append(others, n)
+			}
+		}
+		planned, fits := reschedulePods(displaced, copyNodes(others)), true
+		for _, move := range planned {
+			if move.to == "" {
+				fits = false
+				break
+			}
+		}
+		if !fits {
+			blocked = append(blocked, fmt.Sprintf("%s (pods do not fit elsewhere)", candidate.name))
+			continue
+		}
+
+		for _, move := range planned {
+			findNode(others, move.to).place(move.pod)
+		}
+		left = others
+		removed = append(removed, candidate.name)
+		moves = append(moves, planned...)
+	}
+	return left, removed, blocked, moves
+}
+
+// utilization returns the larger of the CPU and memory fractions requested on the node.
+func utilization(n *clusterNode) float64 {
+	cpu, memory := float64(0), float64(0)
+	if n.cpuAllocatable > 0 {
+		cpu = float64(n.cpuReq) / float64(n.cpuAllocatable)
+	}
+	if n.memoryAllocatable > 0 {
+		memory = float64(n.memoryReq) / float64(n.memoryAllocatable)
+	}
+	if cpu > memory {
+		return cpu
+	}
+	return memory
+}
+
+// scaleDownBlocker returns why the pod keeps its node from being removed, empty if it does not.
+func scaleDownBlocker(p *v1.Pod) string {
+	if metav1.GetControllerOf(p) == nil {
+		return "pod " + podKey(p) + " is not replicated"
+	}
+	for _, volume := range p.Spec.Volumes {
+		if volume.EmptyDir != nil || volume.HostPath != nil {
+			return "pod " + podKey(p) + " has local storage"
+		}
+	}
+	return ""
+}
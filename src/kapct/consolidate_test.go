@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPlanConsolidation(t *testing.T) {
+	idle := workerNode("worker-1", "4", "4Gi", testPod("shop", "web-1", "web", resources("500m", "512Mi")))
+	busy := workerNode("worker-2", "4", "4Gi", testPod("shop", "api-1", "api", resources("2", "2Gi")))
+	cordoned := workerNode("worker-3", "4", "4Gi")
+	cordoned.node.Spec.Unschedulable = true
+	bare := testPod("shop", "debug", "none", resources("4", "4Gi"))
+	bare.OwnerReferences = nil
+	pinned := workerNode("worker-4", "4", "4Gi", bare)
+	workers := []*clusterNode{idle, busy, cordoned, pinned}
+
+	left, removed, blocked, moves := planConsolidation(workers)
+
+	if want := []string{"worker-1"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	if want := []string{"worker-2 (pods do not fit elsewhere)", "worker-4 (pod shop/debug is not replicated)"}; !reflect.DeepEqual(blocked, want) {
+		t.Errorf("blocked = %v, want %v", blocked, want)
+	}
+	if len(moves) != 1 || moves[0].pod.Name != "web-1" || moves[0].to != "worker-2" {
+		t.Errorf("%d moves, want web-1 to worker-2", len(moves))
+	}
+
+	// the removed node and its pods are gone, the pods moved count on the node they went to.
+	names := make([]string, 0, len(left))
+	for _, n := range left {
+		names = append(names, n.name)
+	}
+	if want := []string{"worker-2", "worker-3", "worker-4"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("nodes left = %v, want %v", names, want)
+	}
+	if target := findNode(left, "worker-2"); len(target.pods) != 2 || target.cpuReq != 2500 {
+		t.Errorf("worker-2 runs %d pods requesting %dm, want 2 pods requesting 2500m", len(target.pods), target.cpuReq)
+	}
+
+	// the nodes given are left as they are.
+	if len(idle.pods) != 1 || len(busy.pods) != 1 || idle.node.Spec.Unschedulable {
+		t.Errorf("the nodes given were changed")
+	}
+}
+
+func TestScaleDownBlocker(t *testing.T) {
+	replicated := testPod("shop", "web-1", "web")
+	bare := testPod("shop", "debug", "none")
+	bare.OwnerReferences = []metav1.OwnerReference{}
+	local := testPod("shop", "cache-1", "cache")
+	local.Spec.Volumes = []v1.Volume{{Name: "scratch", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+
+	tests := []struct {
+		pod     v1.Pod
+		blocker string
+	}{
+		{pod: replicated, blocker: ""},
+		{pod: bare, blocker: "pod shop/debug is not replicated"},
+		{pod: local, blocker: "pod shop/cache-1 has local storage"},
+	}
+	for _, tt := range tests {
+		if blocker := scaleDownBlocker(&tt.pod); blocker != tt.blocker {
+			t.Errorf("scaleDownBlocker(%s) = %q, want %q", tt.pod.Name, blocker, tt.blocker)
+		}
+	}
+}
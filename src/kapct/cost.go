@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// nodePrices holds the hourly price of the nodes by their instance type.
+type nodePrices map[string]float64
+
+// nodeCost holds the replicas placed on a node and their share of its hourly price.
+type nodeCost struct {
+	node         string
+	instanceType string
+	price        float64
+	replicas     int64
+	share        float64
+}
+
+// readPrices reads the price file, a map of instance types to their hourly price.
+func readPrices(path string) (nodePrices, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(nodePrices)
+	if err := yaml.Unmarshal(data, &prices); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// instanceType returns the instance type of the node, going by the well known label or its beta predecessor.
+func instanceType(node *v1.Node) string {
+	if instance, ok := node.Labels["node.kubernetes.io/instance-type"]; ok {
+		return instance
+	}
+	return node.Labels["beta.kubernetes.io/instance-type"]
+}
+
+// priceOf returns the instance type and the hourly price of the node, false if the price is not known.
+func (p nodePrices) priceOf(node *v1.Node) (string, float64, bool) {
+	instance := instanceType(node)
+	price, ok := p[instance]
+	return instance, price, ok
+}
+
+// costShare returns the part of the hourly price of a node the replicas take. They take the
+// larger of the fractions of the allocatable CPU and memory they request, the rest of the node
+// is of no use for the other pods once either runs out.
+func costShare(replicas int64, cpuAsk int64, memoryAsk int64, cpuAllocatable int64, memoryAllocatable int64, price float64) float64 {
+	fraction := float64(0)
+	if cpuAllocatable > 0 {
+		fraction = float64(replicas*cpuAsk) / float64(cpuAllocatable)
+	}
+	if memoryAllocatable > 0 {
+		if memoryFraction := float64(replicas*memoryAsk) / float64(memoryAllocatable); memoryFraction > fraction {
+			fraction = memoryFraction
+		}
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	return fraction * price
+}
+
+// printCostEstimate prints the share of the hourly price of every node the replicas are placed
+// on, and the price of the nodes to add for the replicas left out.
+func printCostEstimate(p *tabwriter.Writer, costs []nodeCost, unpriced []string, missing int64, additionalType string, additionalNodes int64, additionalPrice float64) {
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Cost Estimate")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Nodes", "InstanceType", "HourlyPrice", "Replicas", "HourlyShare")
+
+	total := float64(0)
+	for _, cost := range costs {
+		Rows(p, "%2s\t%12s\t%11.3f\t%8d\t%11.3f\t\n", cost.node, cost.instanceType, cost.price, cost.replicas, cost.share)
+		total += cost.share
+	}
+	Columns(p, "\n")
+
+	Rows(p, "%s\t%.3f\t%s\t%.3f\n", "Hourly Cost Share: ", total, "Monthly Cost Share: ", total*730)
+	if missing > 0 {
+		if additionalNodes > 0 {
+			Rows(p, "%s\t%d\t%s\t%s\n", "Additional Nodes Needed: ", additionalNodes, "Additional Node Type: ", additionalType)
+			Rows(p, "%s\t%.3f\t%s\t%.3f\n", "Additional Hourly Cost: ", float64(additionalNodes)*additionalPrice, "Additional Monthly Cost: ", float64(additionalNodes)*additionalPrice*730)
+		} else {
+			Rows(p, " W: %d replicas are left out and no priced node fits them!!!\n", missing)
+		}
+	}
+	if len(unpriced) > 0 {
+		Rows(p, "%s\t%s\t", "Nodes Without Price List: ", VPrint(unpriced))
+	}
+	Columns(p, "\n")
+}
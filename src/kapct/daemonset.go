@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// getDaemonSets lists the DaemonSets of all the namespaces.
+func getDaemonSets(c *k8s.Clientset) []appsv1.DaemonSet {
+	daemonSets, err := c.AppsV1().DaemonSets("").List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting daemon sets!!")
+		panic(err.Error())
+	}
+	return daemonSets.Items
+}
+
+// daemonSetPods returns the pods the DaemonSets would start on a new node, the ones whose
+// node selector and tolerations let them on it. They are owned by their DaemonSet, so the
+// simulations leave them on the node like the DaemonSet pods already running.
+func daemonSetPods(daemonSets []appsv1.DaemonSet, node *v1.Node) []v1.Pod {
+	pods := make([]v1.Pod, 0, len(daemonSets))
+	for i := range daemonSets {
+		ds := &daemonSets[i]
+		if !schedulableOn(&ds.Spec.Template.Spec, node) {
+			continue
+		}
+
+		pod := v1.Pod{ObjectMeta: *ds.Spec.Template.ObjectMeta.DeepCopy(), Spec: *ds.Spec.Template.Spec.DeepCopy()}
+		pod.Name = ds.Name + "-" + node.Name
+		pod.Namespace = ds.Namespace
+		pod.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(ds, appsv1.SchemeGroupVersion.WithKind("DaemonSet"))}
+		pod.Spec.NodeName = node.Name
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// daemonSetTax returns the CPU and memory the DaemonSet pods of a new node request, and their extended resources.
+func daemonSetTax(pods []v1.Pod) (int64, int64, extendedResources) {
+	cpu, memory, extended := int64(0), int64(0), make(extendedResources)
+	for i := range pods {
+		podCPU, podMemory := podRequests(&pods[i])
+		cpu, memory = cpu+podCPU, memory+podMemory
+		for name, value := range podExtendedRequests(&pods[i].Spec) {
+			extended[name] += value
+		}
+	}
+	return cpu, memory, extended
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxDefragMoves bounds the pod moves suggested, past it the disruption is hardly worth it.
+const maxDefragMoves = 50
+
+// fragmentation holds how much of the free resources of a node is left in chunks too small
+// for the pods asked for. Stranded resources are free but useless, the node ran out of
+// the other resource, while fragmented ones are whatever is left after the pods that fit.
+type fragmentation struct {
+	node             string
+	freeCPU          int64
+	freeMemory       int64
+	strandedCPU      int64
+	strandedMemory   int64
+	fragmentedCPU    int64
+	fragmentedMemory int64
+}
+
+// nodeFragmentation calculates the fragmentation of the free resources of a node for the pods asked for.
+func nodeFragmentation(n *clusterNode, cpuAsk int64, memoryAsk int64) fragmentation {
+	remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+	frag := fragmentation{node: n.name, freeCPU: remainingCPUReq, freeMemory: remainingMemoryReq}
+
+	if remainingMemoryReq < memoryAsk || freePods <= 0 {
+		frag.strandedCPU = remainingCPUReq
+	}
+	if remainingCPUReq < cpuAsk || freePods <= 0 {
+		frag.strandedMemory = remainingMemoryReq
+	}
+
+	fit := nodeFit(remainingCPUReq, remainingMemoryReq, cpuAsk, memoryAsk, freePods)
+	frag.fragmentedCPU = remainingCPUReq - fit*cpuAsk
+	frag.fragmentedMemory = remainingMemoryReq - fit*memoryAsk
+	return frag
+}
+
+// isReplicaSetPod tells if the pod is controlled by a ReplicaSet, evicting it only gets it recreated elsewhere.
+func isReplicaSetPod(p *v1.Pod) bool {
+	owner := metav1.GetControllerOf(p)
+	return owner != nil && owner.Kind == "ReplicaSet"
+}
+
+// totalFit returns how many pods, each asking for cpuAsk and memoryAsk, fit on all the nodes.
+func totalFit(nodes []*clusterNode, cpuAsk int64, memoryAsk int64) int64 {
+	total := int64(0)
+	for _, n := range nodes {
+		if !n.node.Spec.Unschedulable {
+			remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+			total += nodeFit(remainingCPUReq, remainingMemoryReq, cpuAsk, memoryAsk, freePods)
+		}
+	}
+	return total
+}
+
+// planDefragmentation suggests the ReplicaSet pods to evict and where they would be rescheduled,
+// so that the free resources come together and fit the replicas asked for. Every round it tries
+// to empty each node a little, the fewest pod moves that fit one more replica win the round.
+func planDefragmentation(nodes []*clusterNode, cpuAsk int64, memoryAsk int64, replicaAsk int64) []podMove {
+	moves := make([]podMove, 0, 3)
+
+	for len(moves) < maxDefragMoves && totalFit(nodes, cpuAsk, memoryAsk) < replicaAsk {
+		before := totalFit(nodes, cpuAsk, memoryAsk)
+		var best []podMove
+
+		for i := range nodes {
+			trial := copyNodes(nodes)
+			source := trial[i]
+			plan := make([]podMove, 0, 3)
+
+			for _, p := range append([]v1.Pod{}, source.pods...) {
+				if best != nil && len(plan) >= len(best) {
+					break
+				}
+				if !isReplicaSetPod(&p) {
+					continue
+				}
+				for _, target := range trial {
+					if target != source && !target.node.Spec.Unschedulable && target.fits(&p) {
+						source.evict(&p)
+						target.place(p)
+						plan = append(plan, podMove{pod: p, from: source.name, to: target.name})
+						break
+					}
+				}
+				if totalFit(trial, cpuAsk, memoryAsk) > before {
+					best = plan
+					break
+				}
+			}
+		}
+
+		if best == nil {
+			break
+		}
+		for _, move := range best {
+			findNode(nodes, move.from).evict(&move.pod)
+			findNode(nodes, move.to).place(move.pod)
+		}
+		moves = append(moves, best...)
+	}
+
+	return moves
+}
+
+// printFragmentation prints the fragmentation of the free resources per node and, when the
+// replicas asked for do not fit, the pod moves which would make room for them.
+func printFragmentation(p *tabwriter.Writer, nodes []*clusterNode, cpuAsk int64, memoryAsk int64, replicaAsk int64) {
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Fragmentation Per Node")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Node", "FreeCpu", "FreeMem", "StrandedCpu", "StrandedMem", "FragCpu", "FragMem")
+
+	freeCPU, freeMemory, fragmentedCPU, fragmentedMemory := int64(0), int64(0), int64(0), int64(0)
+	for _, n := range nodes {
+		frag := nodeFragmentation(n, cpuAsk, memoryAsk)
+		Rows(p, "%2s\t%6dm\t%6dM\t%10dm\t%10dM\t%6dm\t%6dM\t\n", frag.node, frag.freeCPU, frag.freeMemory/MEGABYTE, frag.strandedCPU, frag.strandedMemory/MEGABYTE, frag.fragmentedCPU, frag.fragmentedMemory/MEGABYTE)
+		freeCPU, freeMemory = freeCPU+frag.freeCPU, freeMemory+frag.freeMemory
+		fragmentedCPU, fragmentedMemory = fragmentedCPU+frag.fragmentedCPU, fragmentedMemory+frag.fragmentedMemory
+	}
+	Columns(p, "\n")
+
+	cpuScore, memoryScore := float64(0), float64(0)
+	if freeCPU > 0 {
+		cpuScore = float64(fragmentedCPU) / float64(freeCPU) * 100
+	}
+	if freeMemory > 0 {
+		memoryScore = float64(fragmentedMemory) / float64(freeMemory) * 100
+	}
+	Rows(p, "%s\t%4.2f%%\t%s\t%4.2f%%\n", "CPU Fragmentation: ", cpuScore, "Memory Fragmentation: ", memoryScore)
+
+	if totalFit(nodes, cpuAsk, memoryAsk) >= replicaAsk {
+		Columns(p, "\n")
+		return
+	}
+
+	moves := planDefragmentation(copyNodes(nodes), cpuAsk, memoryAsk, replicaAsk)
+	printMoves(p, "Defragmentation Moves", moves)
+
+	after := copyNodes(nodes)
+	for _, move := range moves {
+		findNode(after, move.from).evict(&move.pod)
+		findNode(after, move.to).place(move.pod)
+	}
+	if totalFit(after, cpuAsk, memoryAsk) >= replicaAsk {
+		Rows(p, "%s\t%d\t%s\t%s\n", "Pods To Move: ", len(moves), "Is Scheduleable After Moves?: ", "True")
+	} else {
+		Rows(p, "%s\t%d\t%s\t%s\n", "Pods To Move: ", len(moves), "Is Scheduleable After Moves?: ", "False")
+	}
+	if len(moves) == maxDefragMoves {
+		Rows(p, " W: stopped suggesting moves at %d pods!!!\n", maxDefragMoves)
+	}
+	Columns(p, "\n")
+}
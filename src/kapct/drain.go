@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// drainSim simulates draining the given nodes, the pods they run are rescheduled on
+// the rest of the worker nodes and the PodDisruptionBudgets are checked for the evictions.
+func drainSim(args []string) {
+	f := flag.NewFlagSet("drain-sim", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct drain-sim [options] node...\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if f.NArg() == 0 {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+	workers := getWorkerNodes(c)
+
+	// take the drained nodes out, what they run has to find room on the rest.
+	drained, evicted := make([]string, 0, f.NArg()), make([]podMove, 0, 3)
+	for _, name := range f.Args() {
+		n := findNode(workers, name)
+		if n == nil {
+			fmt.Printf(" W: %s is not a healthy worker node, skipping it!!!\n", name)
+			continue
+		}
+		drained = append(drained, n.name)
+		for _, p := range n.pods {
+			if !isDaemonSetPod(&p) && !isMirrorPod(&p) {
+				evicted = append(evicted, podMove{pod: p, from: n.name})
+			}
+		}
+		n.node.Spec.Unschedulable = true
+	}
+
+	moves := reschedulePods(evicted, workers)
+	blockingPDBs := checkDisruptionBudgets(c, moves)
+
+	unschedulable := make([]string, 0, 3)
+	for _, move := range moves {
+		if move.to == "" {
+			unschedulable = append(unschedulable, fmt.Sprintf("%s (%s)", podKey(&move.pod), move.from))
+		}
+	}
+
+	printMoves(w, "Drain Simulation", moves)
+	Rows(w, "%s\t%d\t\n", "Nodes To Drain: ", len(drained))
+	Rows(w, "%s\t%s\t", "Drained Nodes List: ", VPrint(drained))
+	Rows(w, "%s\t%d\t%s\t%d\n", "Pods To Reschedule: ", len(moves), "Unschedulable Pods: ", len(unschedulable))
+	Rows(w, "%s\t%s\t", "Unschedulable Pods List: ", VPrint(unschedulable))
+	Rows(w, "%s\t%d\t\n", "Blocking PodDisruptionBudgets: ", len(blockingPDBs))
+	Rows(w, "%s\t%s\t", "Blocking PodDisruptionBudgets List: ", VPrint(blockingPDBs))
+	Columns(w, "\n")
+	if len(drained) > 0 && len(unschedulable) == 0 && len(blockingPDBs) == 0 {
+		Rows(w, "%s\t%s\t\n", "Is Drain Safe?: ", "True")
+	} else {
+		Rows(w, "%s\t%s\t\n", "Is Drain Safe?: ", "False")
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// checkDisruptionBudgets returns the PodDisruptionBudgets which do not allow as many
+// disruptions as the moved pods they select.
+func checkDisruptionBudgets(c *k8s.Clientset, moves []podMove) []string {
+	pdbs, err := c.PolicyV1beta1().PodDisruptionBudgets("").List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting pod disruption budgets!!")
+		panic(err.Error())
+	}
+
+	blocking := make([]string, 0, 3)
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+
+		disrupted := int32(0)
+		for _, move := range moves {
+			if move.pod.Namespace == pdb.Namespace && selector.Matches(labels.Set(move.pod.Labels)) {
+				disrupted++
+			}
+		}
+		if disrupted > pdb.Status.PodDisruptionsAllowed {
+			blocking = append(blocking, fmt.Sprintf("%s/%s (disrupted %d, allowed %d)", pdb.Namespace, pdb.Name, disrupted, pdb.Status.PodDisruptionsAllowed))
+		}
+	}
+	return blocking
+}
+
+// printMoves prints the pods along with the nodes they are moved from and to.
+func printMoves(p *tabwriter.Writer, title string, moves []podMove) {
+	Columns(p, "\n")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", title)
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Pod", "CpuReq", "MemReq", "From", "To")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	for _, move := range moves {
+		cpu, memory := podRequests(&move.pod)
+		to := move.to
+		if to == "" {
+			to = "Unschedulable"
+		}
+		Rows(p, "%2s\t%dm\t%dM\t%s\t%s\t\n", podKey(&move.pod), cpu, memory/MEGABYTE, move.from, to)
+	}
+	Columns(p, "\n")
+}
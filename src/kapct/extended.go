@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// extendedResources maps the name of an extended resource, like nvidia.com/gpu
+// or hugepages-2Mi, to its quantity. It is used for what was requested via
+// STDIN as well as for what is allocated or allocatable on a node.
+type extendedResources map[string]int64
+
+// extendedUsage holds the accounting of a single extended resource on a node.
+type extendedUsage struct {
+	node        string
+	name        string
+	allocated   int64
+	allocatable int64
+}
+
+// String implements flag.Value and prints the resources in name=quantity form.
+func (e extendedResources) String() string {
+	pairs := make([]string, 0, len(e))
+	for _, name := range e.Names() {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", name, e[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value, every occurrence of the flag adds one resource
+// given in name=quantity form, e.g. nvidia.com/gpu=1 or hugepages-2Mi=512Mi.
+func (e extendedResources) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("%q is not in name=quantity format", value)
+	}
+	if isStandardResource(kv[0]) {
+		return fmt.Errorf("%q can not be requested as an extended resource, use the dedicated switch instead", kv[0])
+	}
+	quantity, err := resource.ParseQuantity(kv[1])
+	if err != nil {
+		return fmt.Errorf("%q is not a valid quantity for %s", kv[1], kv[0])
+	}
+	e[kv[0]] = quantity.Value()
+	return nil
+}
+
+// Names returns the sorted resource names, so the output stays in the same order between the runs.
+func (e extendedResources) Names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isStandardResource tells if the resource is one of those kapct handles with its own switches.
+func isStandardResource(name string) bool {
+	switch v1.ResourceName(name) {
+	case v1.ResourceCPU, v1.ResourceMemory, v1.ResourcePods, v1.ResourceStorage, v1.ResourceEphemeralStorage:
+		return true
+	}
+	return false
+}
+
+// nodeExtendedAllocatable returns the extended resources advertised by the node.
+func nodeExtendedAllocatable(alloc v1.ResourceList) extendedResources {
+	ext := make(extendedResources)
+	for name, quantity := range alloc {
+		if !isStandardResource(string(name)) {
+			ext[string(name)] = quantity.Value()
+		}
+	}
+	return ext
+}
+
+// addExtendedRequests adds the extended resources of a container to the total.
+// Extended resources can not be overcommitted, so when only a limit is set
+// the request is defaulted to it, the same as the API server does.
+func addExtendedRequests(total extendedResources, resources v1.ResourceRequirements) {
+	for name, quantity := range resources.Limits {
+		if _, ok := resources.Requests[name]; !ok && !isStandardResource(string(name)) {
+			total[string(name)] += quantity.Value()
+		}
+	}
+	for name, quantity := range resources.Requests {
+		if !isStandardResource(string(name)) {
+			total[string(name)] += quantity.Value()
+		}
+	}
+}
+
+// podExtendedRequests returns the extended resources the pod spec needs on a node, the sum over
+// its containers or the largest of its init containers, whichever is more, as effectiveResources
+// does for the rest.
+func podExtendedRequests(spec *v1.PodSpec) extendedResources {
+	total := make(extendedResources)
+	for _, container := range spec.Containers {
+		addExtendedRequests(total, container.Resources)
+	}
+	for _, container := range spec.InitContainers {
+		init := make(extendedResources)
+		addExtendedRequests(init, container.Resources)
+		for name, value := range init {
+			if value > total[name] {
+				total[name] = value
+			}
+		}
+	}
+	return total
+}
+
+// extendedSpinable calculates how many pods, each asking for extAsk, fit in the
+// remaining extended resources of a node. The resources which can not fit even
+// a single pod are marked true in the returned crunch map.
+func extendedSpinable(extAllocatable extendedResources, extAllocated extendedResources, extAsk extendedResources, podAllocatable int64) (int64, map[string]bool) {
+
+	spinable := podAllocatable
+	crunch := make(map[string]bool)
+
+	for name, ask := range extAsk {
+		if ask <= 0 {
+			continue
+		}
+		// a node not advertising the resource at all has no room for it.
+		fit := (extAllocatable[name] - extAllocated[name]) / ask
+		if fit < 1 {
+			fit = 0
+			crunch[name] = true
+		}
+		if fit < spinable {
+			spinable = fit
+		}
+	}
+
+	return spinable, crunch
+}
+
+// crunchLabel returns the column name of the crunch column for an extended resource.
+func crunchLabel(name string) string {
+	return name[strings.LastIndex(name, "/")+1:] + "Crunch"
+}
+
+// printExtendedAccounting prints allocated against allocatable of every extended resource per node.
+func printExtendedAccounting(p *tabwriter.Writer, accounting []extendedUsage) {
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Extended Resources Per Node")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t\n", "Node", "Resource", "Allocated", "Allocatable")
+	for _, usage := range accounting {
+		Rows(p, "%2s\t%s\t%9d\t%11d\t\n", usage.node, usage.name, usage.allocated, usage.allocatable)
+	}
+	Columns(p, "\n")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// getServices lists the Services of all the namespaces.
+func getServices(c *k8s.Clientset) []v1.Service {
+	services, err := c.CoreV1().Services("").List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting services!!")
+		panic(err.Error())
+	}
+	return services.Items
+}
+
+// wantedHostPorts returns the container ports of the pod which bind to a port on the host.
+func wantedHostPorts(spec *v1.PodSpec) []v1.ContainerPort {
+	ports := make([]v1.ContainerPort, 0, 3)
+	for _, container := range spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort > 0 {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// hostPortConflicts returns the wanted host ports which are already bound by the pods on a node.
+func hostPortConflicts(pods []v1.Pod, wanted []v1.ContainerPort) []string {
+	conflicts := make([]string, 0, len(wanted))
+	for _, want := range wanted {
+	pods:
+		for _, p := range pods {
+			for _, port := range wantedHostPorts(&p.Spec) {
+				if hostPortsOverlap(want, port) {
+					conflicts = append(conflicts, fmt.Sprintf("%d/%s(%s)", want.HostPort, hostPortProtocol(want), p.Name))
+					break pods
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// nodePortConflicts returns the wanted host ports which a Service already takes as its node port,
+// kube-proxy holds a node port on every node so such a host port conflicts on any node.
+func nodePortConflicts(services []v1.Service, wanted []v1.ContainerPort) []string {
+	conflicts := make([]string, 0, len(wanted))
+	for _, want := range wanted {
+	services:
+		for _, s := range services {
+			for _, port := range s.Spec.Ports {
+				if port.NodePort == want.HostPort && servicePortProtocol(port) == hostPortProtocol(want) {
+					conflicts = append(conflicts, fmt.Sprintf("%d/%s(service %s/%s)", want.HostPort, hostPortProtocol(want), s.Namespace, s.Name))
+					break services
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// hostPortsOverlap tells if two host ports can not be bound on the same node at once,
+// a port bound to all the addresses conflicts with the same port on any address.
+func hostPortsOverlap(a v1.ContainerPort, b v1.ContainerPort) bool {
+	if a.HostPort != b.HostPort || hostPortProtocol(a) != hostPortProtocol(b) {
+		return false
+	}
+	return a.HostIP == b.HostIP || hostPortIP(a) == "0.0.0.0" || hostPortIP(b) == "0.0.0.0"
+}
+
+// hostPortProtocol returns the protocol of the port, defaulted the same way as the API server does.
+func hostPortProtocol(port v1.ContainerPort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// servicePortProtocol returns the protocol of the Service port, defaulted the same way as the API server does.
+func servicePortProtocol(port v1.ServicePort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// hostPortIP returns the host address the port binds to, an empty address binds to all of them.
+func hostPortIP(port v1.ContainerPort) string {
+	if port.HostIP == "" {
+		return "0.0.0.0"
+	}
+	return port.HostIP
+}
+
+// hostPortCap returns the most pods of the workload a node can take because of the
+// host ports, every replica binds the same ports so only one of them fits per node.
+func hostPortCap(wanted []v1.ContainerPort, conflicts []string, podAllocatable int64) int64 {
+	switch {
+	case len(wanted) == 0:
+		return podAllocatable
+	case len(conflicts) > 0:
+		return 0
+	default:
+		return 1
+	}
+}
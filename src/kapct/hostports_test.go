@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodePortConflicts(t *testing.T) {
+	services := []v1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}, Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80, NodePort: 30080}}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "dns", Name: "resolver"}, Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 53, NodePort: 30053, Protocol: v1.ProtocolUDP}}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}, Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 8080}}}},
+	}
+
+	tests := []struct {
+		name      string
+		wanted    []v1.ContainerPort
+		conflicts []string
+	}{
+		{name: "node port of the same protocol", wanted: []v1.ContainerPort{{HostPort: 30080}}, conflicts: []string{"30080/TCP(service shop/web)"}},
+		{name: "node port of another protocol", wanted: []v1.ContainerPort{{HostPort: 30053}}, conflicts: []string{}},
+		{name: "udp node port", wanted: []v1.ContainerPort{{HostPort: 30053, Protocol: v1.ProtocolUDP}}, conflicts: []string{"30053/UDP(service dns/resolver)"}},
+		{name: "service port is not a node port", wanted: []v1.ContainerPort{{HostPort: 8080}}, conflicts: []string{}},
+		{name: "no host ports", conflicts: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if conflicts := nodePortConflicts(services, tt.wanted); !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %v, want %v", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestWantedHostPorts(t *testing.T) {
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Ports: []v1.ContainerPort{{ContainerPort: 9000, HostPort: 9000}}}},
+		Containers: []v1.Container{
+			{Name: "web", Ports: []v1.ContainerPort{{ContainerPort: 80, HostPort: 8080}, {ContainerPort: 443}}},
+			{Name: "dns", Ports: []v1.ContainerPort{{ContainerPort: 53, HostPort: 53, Protocol: v1.ProtocolUDP}}},
+		},
+	}
+	want := []v1.ContainerPort{{ContainerPort: 80, HostPort: 8080}, {ContainerPort: 53, HostPort: 53, Protocol: v1.ProtocolUDP}}
+	if ports := wantedHostPorts(spec); !reflect.DeepEqual(ports, want) {
+		t.Errorf("wantedHostPorts = %+v, want %+v", ports, want)
+	}
+}
+
+func TestHostPortConflicts(t *testing.T) {
+	bound := func(name string, ports ...v1.ContainerPort) v1.Pod {
+		p := testPod("shop", name, "proxy")
+		p.Spec.Containers = []v1.Container{{Name: "app", Ports: ports}}
+		return p
+	}
+	pods := []v1.Pod{
+		bound("proxy-1", v1.ContainerPort{HostPort: 8080}),
+		bound("proxy-2", v1.ContainerPort{HostPort: 8080}),
+		bound("dns-1", v1.ContainerPort{HostPort: 53, Protocol: v1.ProtocolUDP, HostIP: "10.0.0.1"}),
+	}
+
+	tests := []struct {
+		name      string
+		wanted    []v1.ContainerPort
+		conflicts []string
+	}{
+		{name: "same port names the first pod", wanted: []v1.ContainerPort{{HostPort: 8080}}, conflicts: []string{"8080/TCP(proxy-1)"}},
+		{name: "same port of another protocol", wanted: []v1.ContainerPort{{HostPort: 8080, Protocol: v1.ProtocolUDP}}, conflicts: []string{}},
+		{name: "all the addresses take every one", wanted: []v1.ContainerPort{{HostPort: 8080, HostIP: "10.0.0.2"}}, conflicts: []string{"8080/TCP(proxy-1)"}},
+		{name: "same address", wanted: []v1.ContainerPort{{HostPort: 53, Protocol: v1.ProtocolUDP, HostIP: "10.0.0.1"}}, conflicts: []string{"53/UDP(dns-1)"}},
+		{name: "another address", wanted: []v1.ContainerPort{{HostPort: 53, Protocol: v1.ProtocolUDP, HostIP: "10.0.0.2"}}, conflicts: []string{}},
+		{name: "every address", wanted: []v1.ContainerPort{{HostPort: 53, Protocol: v1.ProtocolUDP}}, conflicts: []string{"53/UDP(dns-1)"}},
+		{name: "one conflict per port", wanted: []v1.ContainerPort{{HostPort: 8080}, {HostPort: 9090}, {HostPort: 53, Protocol: v1.ProtocolUDP}}, conflicts: []string{"8080/TCP(proxy-1)", "53/UDP(dns-1)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if conflicts := hostPortConflicts(pods, tt.wanted); !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("conflicts = %v, want %v", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestHostPortCap(t *testing.T) {
+	tests := []struct {
+		name      string
+		wanted    []v1.ContainerPort
+		conflicts []string
+		portCap   int64
+	}{
+		{name: "no host ports", portCap: 110},
+		{name: "one pod per node", wanted: []v1.ContainerPort{{HostPort: 8080}}, portCap: 1},
+		{name: "taken port", wanted: []v1.ContainerPort{{HostPort: 8080}}, conflicts: []string{"8080/TCP(proxy-1)"}, portCap: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if portCap := hostPortCap(tt.wanted, tt.conflicts, 110); portCap != tt.portCap {
+				t.Errorf("hostPortCap = %d, want %d", portCap, tt.portCap)
+			}
+		})
+	}
+}
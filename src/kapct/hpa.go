@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hpaCeiling holds the replicas a HorizontalPodAutoscaler may add over what its target runs.
+type hpaCeiling struct {
+	name        string
+	target      string
+	running     int32
+	maxReplicas int32
+	pods        []podMove
+	fit         int
+	fitAll      int
+}
+
+// hpaAudit checks if the cluster could hold every HorizontalPodAutoscaler at its
+// maxReplicas, each on its own as well as all of them at the same time.
+func hpaAudit(args []string) {
+	f := flag.NewFlagSet("hpa-audit", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct hpa-audit [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+
+	hpas, err := c.AutoscalingV1().HorizontalPodAutoscalers("").List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting horizontal pod autoscalers!!")
+		panic(err.Error())
+	}
+
+	ceilings := make([]*hpaCeiling, 0, len(hpas.Items))
+	owners := make(map[string]*hpaCeiling)
+	for _, hpa := range hpas.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		template, running, err := getWorkloadTemplate(c, strings.ToLower(ref.Kind), hpa.Namespace, ref.Name)
+		if err != nil {
+			fmt.Printf(" W: skipping %s/%s, %s!!!\n", hpa.Namespace, hpa.Name, err.Error())
+			continue
+		}
+
+		ceiling := &hpaCeiling{
+			name:        hpa.Namespace + "/" + hpa.Name,
+			target:      strings.ToLower(ref.Kind) + "/" + ref.Name,
+			running:     running,
+			maxReplicas: hpa.Spec.MaxReplicas,
+		}
+		for i := running; i < hpa.Spec.MaxReplicas; i++ {
+			p := v1.Pod{ObjectMeta: *template.ObjectMeta.DeepCopy(), Spec: *template.Spec.DeepCopy()}
+			// named after the autoscaler, two of them in a namespace may target workloads of the same name.
+			p.Namespace, p.Name = hpa.Namespace, fmt.Sprintf("%s-hpa-%d", hpa.Name, i+1)
+			ceiling.pods = append(ceiling.pods, podMove{pod: p})
+			owners[podKey(&p)] = ceiling
+		}
+		ceilings = append(ceilings, ceiling)
+	}
+
+	// every autoscaler on its own gets the cluster as it is now.
+	workers := getWorkerNodes(c)
+	all := make([]podMove, 0, len(owners))
+	for _, ceiling := range ceilings {
+		for _, move := range reschedulePods(ceiling.pods, copyNodes(workers)) {
+			if move.to != "" {
+				ceiling.fit++
+			}
+		}
+		all = append(all, ceiling.pods...)
+	}
+
+	// then all of them scale to their maximum at once, sharing the room left.
+	allFit := true
+	for _, move := range reschedulePods(all, copyNodes(workers)) {
+		if move.to != "" {
+			owners[podKey(&move.pod)].fitAll++
+		} else {
+			allFit = false
+		}
+	}
+
+	printHPACeilings(w, ceilings, allFit)
+}
+
+// printHPACeilings prints the replicas each autoscaler may add and how many of them fit.
+func printHPACeilings(w *tabwriter.Writer, ceilings []*hpaCeiling, allFit bool) {
+	Columns(w, "\n")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%40s\t\n", "HPA Ceiling Audit")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "HPA", "Target", "Running", "Max", "Extra", "Fits", "FitsAll", "Reachable")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+
+	unreachable := make([]string, 0, 3)
+	for _, ceiling := range ceilings {
+		reachable := ceiling.fit == len(ceiling.pods)
+		if !reachable {
+			unreachable = append(unreachable, fmt.Sprintf("%s (%d of %d extra replicas fit)", ceiling.name, ceiling.fit, len(ceiling.pods)))
+		}
+		Rows(w, "%2s\t%s\t%7d\t%3d\t%5d\t%4d\t%7d\t%9t\t\n", ceiling.name, ceiling.target, ceiling.running, ceiling.maxReplicas, len(ceiling.pods), ceiling.fit, ceiling.fitAll, reachable)
+	}
+	Columns(w, "\n")
+
+	Rows(w, "%s\t%d\t%s\t%d\n", "HPAs Audited: ", len(ceilings), "Unreachable HPAs: ", len(unreachable))
+	Rows(w, "%s\t%s\t", "Unreachable HPAs List: ", VPrint(unreachable))
+	if allFit {
+		Rows(w, "%s\t%s\t\n", "Do All HPAs Fit At Max?: ", "True")
+	} else {
+		Rows(w, "%s\t%s\t\n", "Do All HPAs Fit At Max?: ", "False")
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
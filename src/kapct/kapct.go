@@ -21,11 +21,14 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"unicode"
 
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	k8s "k8s.io/client-go/kubernetes"
@@ -52,38 +55,81 @@ const (
 
 type specs map[string]interface{}
 
+// commands maps the sub commands of kapct to the functions running them.
+var commands = map[string]func(args []string){
+	"batch":         batch,
+	"consolidate":   consolidate,
+	"drain-sim":     drainSim,
+	"hpa-audit":     hpaAudit,
+	"lint":          lint,
+	"max-request":   maxRequest,
+	"oom-risk":      oomRisk,
+	"rollout-check": rolloutCheck,
+	"scale-check":   scaleCheck,
+	"showback":      showback,
+	"suggest":       suggest,
+	"upgrade-sim":   upgradeSim,
+	"usage":         usage,
+}
+
 func main() {
 	runtime.GOMAXPROCS(2)
+
+	// sub commands parse their own switches, anything else is the capacity check.
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	// decalare all required flag variables
 	var kubeconfig *string
 	var cpuAsk string
 	var memoryAsk string
 	var cpuLimitAsk string
 	var memoryLimitAsk string
+	var storageAsk string
+	var storageLimitAsk string
+	var workloadFile string
+	var catalogFile string
+	var priceFile string
+	var priorityClass string
 	var replicaAsk int
 	var version bool
+	extendedAsk := make(extendedResources)
 	var legends bool
+	var defrag bool
+	edits := nodeEdits{resize: make(nodeResizes)}
 
 	// read the kubeconfig file from program switch, if not available from environment variable or default location.
-	if kubeConfigFile := getKubeConfig(); kubeConfigFile != "" {
-		kubeconfig = flag.String("kubeconfig", kubeConfigFile, "(optional) absolute path to the kubeconfig file")
-	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
-	}
+	kubeconfig = kubeConfigFlag(flag.CommandLine)
 
 	// declare the flags and set the defaults
 	flag.StringVar(&cpuAsk, "cpureq", "100m", "amount of CPU you desire in m(milicores), use only string formatted interger for cores.")
 	flag.StringVar(&memoryAsk, "memreq", "1G", "amount of memory you desire in K(KB),M(MB),G(GB),T(TB)")
 	flag.StringVar(&cpuLimitAsk, "cpulimit", "100m", "amount of CPU you desire in m(milicores), use only string formatted interger for cores.")
 	flag.StringVar(&memoryLimitAsk, "memlimit", "1G", "amount of memory you desire in K(KB),M(MB),G(GB),T(TB)")
+	flag.StringVar(&storageAsk, "storagereq", "0", "amount of ephemeral storage you desire in K(KB),M(MB),G(GB),T(TB)")
+	flag.StringVar(&storageLimitAsk, "storagelimit", "0", "amount of ephemeral storage you desire in K(KB),M(MB),G(GB),T(TB)")
+	flag.StringVar(&workloadFile, "f", "", "(optional) workload definition file, the host ports of its containers are taken into account.")
+	flag.StringVar(&catalogFile, "catalog", "", "(optional) instance catalog file, the node templates the cluster could be scaled up with when the replicas do not fit.")
+	flag.StringVar(&priceFile, "prices", "", "(optional) price file of the hourly price of the nodes by their node.kubernetes.io/instance-type label, the cost of the replicas is estimated.")
+	thresholds := overcommitFlags(flag.CommandLine)
+	flag.StringVar(&priorityClass, "priority-class", "", "(optional) priority class of the pods you desire, lower priority pods are considered for preemption.")
 	flag.IntVar(&replicaAsk, "replicas", 1, "number of replicas, you may want to deploy.")
+	flag.Var(extendedAsk, "request", "extended resource you desire in name=quantity format e.g. nvidia.com/gpu=1, repeat the switch for more than one resource.")
 	flag.BoolVar(&version, "version", false, "display version and exit.")
 	flag.BoolVar(&legends, "legends", false, "print legends and exit.")
+	flag.Var(&edits.add, "add-node", "(optional) what-if node definition file and number of nodes to add from it in template.yaml:count format, repeat the switch for more than one template.")
+	flag.Var(&edits.remove, "remove-node", "(optional) what-if name of a node to remove, its pods are rescheduled on the rest first; repeat the switch for more than one node.")
+	flag.Var(edits.resize, "resize-node", "(optional) what-if new capacity of a node in node=resource:quantity,... format e.g. worker-2=cpu:16,memory:64Gi, repeat the switch for more than one node.")
+	flag.BoolVar(&defrag, "defrag", false, "print fragmentation of the free resources and the pod moves making room for the replicas, if they do not fit.")
+	namespaceSwitches := namespaceFlags(flag.CommandLine, true)
 	flag.Parse()
 
 	// initialize tabwriter for formatted printing
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 6, 3, ' ', tabwriter.AlignRight)
+	w := newTabWriter()
 
 	if version {
 		printversion()
@@ -93,27 +139,57 @@ func main() {
 		printLegends(w)
 	}
 
-	loadConfig, err := cmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		fmt.Println("There is a problem loading kubeconfig file!!")
-		panic(err.Error())
-	}
-
-	newClientSet, err := k8s.NewForConfig(loadConfig)
-	if err != nil {
-		fmt.Println("There is a problem creating a client!!")
-		panic(err.Error())
+	hostPorts, workloadSpec := make([]v1.ContainerPort, 0, 3), &v1.PodSpec{}
+	if workloadFile != "" {
+		template, err := readWorkload(workloadFile)
+		if err != nil {
+			fmt.Println("There is a problem reading workload definition!!")
+			panic(err.Error())
+		}
+		hostPorts, workloadSpec = wantedHostPorts(&template.Spec), &template.Spec
 	}
 
 	/* get nodes details
 	   This function does the most of heavy lifting for the program
 	   It is a manager function.
 	*/
-	getNodeResources(w, newClientSet, cpuAsk, memoryAsk, cpuLimitAsk, memoryLimitAsk, replicaAsk)
+	namespaces, err := namespaceSwitches.filter()
+	if err != nil {
+		fmt.Println("There is a problem parsing exempt-selector!!")
+		panic(err.Error())
+	}
+
+	var prices nodePrices
+	if priceFile != "" {
+		if prices, err = readPrices(priceFile); err != nil {
+			fmt.Println("There is a problem reading price file!!")
+			panic(err.Error())
+		}
+	}
+
+	c := newClientSet(*kubeconfig)
+	netReplicas := getNodeResources(w, c, cpuAsk, memoryAsk, cpuLimitAsk, memoryLimitAsk, storageAsk, storageLimitAsk, replicaAsk, extendedAsk, hostPorts, priorityClass, defrag, edits, prices, thresholds, namespaces)
+
+	// the replicas left out need new nodes, plan them out of the catalog.
+	if catalogFile != "" && netReplicas < int64(replicaAsk) {
+		catalog, err := readCatalog(catalogFile)
+		if err != nil {
+			fmt.Println("There is a problem reading instance catalog!!")
+			panic(err.Error())
+		}
+		missing := int64(replicaAsk) - netReplicas
+		nodePorts := make([]string, 0, 3)
+		if len(hostPorts) > 0 {
+			nodePorts = nodePortConflicts(getServices(c), hostPorts)
+		}
+		options := planScaleUp(catalog.Templates, getDaemonSets(c), workloadSpec, cpuToInt64(cpuAsk), ToBytes(memoryAsk), extendedAsk, hostPorts, nodePorts, missing)
+		printScaleUpPlan(w, options, missing)
+		w.Flush()
+	}
 }
 
-// getNodeResources fetches allocated resources for each nodes.
-func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memoryAsk string, cpuLimitAsk string, memoryLimitAsk string, replicaAsk int) {
+// getNodeResources fetches allocated resources for each nodes and returns how many of the replicas fit.
+func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memoryAsk string, cpuLimitAsk string, memoryLimitAsk string, storageAsk string, storageLimitAsk string, replicaAsk int, extendedAsk extendedResources, hostPorts []v1.ContainerPort, priorityClass string, defrag bool, edits nodeEdits, prices nodePrices, thresholds *overcommitThresholds, filter namespaceFilter) int64 {
 
 	var header bool
 	var node int
@@ -131,6 +207,7 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 	for n := 0; n < len(namespaces.Items); n++ {
 		namespaceList = append(namespaceList, namespaces.Items[n].Name)
 	}
+	exempt := filter.exemptNamespaces(namespaces.Items)
 
 	// get node list based on node status, should not be unknown
 	nodes, err := c.CoreV1().Nodes().List(metav1.ListOptions{})
@@ -139,40 +216,72 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 		panic(err.Error())
 	}
 
+	// the what-if changes apply to the node list only, the cluster is left as it is.
+	simulated, daemonSets, removed := make(map[string]string), make([]appsv1.DaemonSet, 0), make([]v1.Node, 0)
+	if !edits.empty() {
+		nodes.Items, removed, simulated = applyNodeEdits(nodes.Items, edits)
+	}
+	if len(edits.add) > 0 {
+		daemonSets = getDaemonSets(c)
+	}
+
+	// the pods of the removed nodes find room on the rest first, the replicas get what is left.
+	rehomed, unplaced := make(map[string][]v1.Pod), make([]string, 0)
+	if len(removed) > 0 {
+		rehomed, unplaced = rehomePods(c, nodes.Items, removed, simulated, daemonSets, namespaceList)
+	}
+
 	// initialize lists and maps
-	unHealthyNodes, temp, errorredPods := make([]string, 0, 3), "ok", 0
+	unHealthyNodes, errorredPods := make([]string, 0, 3), 0
 	netReplicas, overcommittedNodes := int64(0), make([]string, 0, 3)
-	conditions := make(map[string]string)
+	extendedAccounting := make([]extendedUsage, 0, 3)
+	portConflictNodes, nodePorts := make([]string, 0, 3), make([]string, 0, 3)
+	if len(hostPorts) > 0 {
+		nodePorts = nodePortConflicts(getServices(c), hostPorts)
+	}
+	largestCPUFit, largestMemoryFit, largestPodsFit := "none", "none", "none"
+	largestCPU, largestMemory, largestPods := int64(0), int64(0), int64(0)
+	preemptionCandidates := make([]preemptionCandidate, 0, 3)
+	clusterNodes := make([]*clusterNode, 0, 3)
+	costs, unpriced, placed := make([]nodeCost, 0, 3), make([]string, 0, 3), int64(0)
+	var pricedNode *v1.Node
+
+	// pods of a priority class may preempt the pods with lower priority.
+	priority, preempts := int32(0), false
+	if priorityClass != "" {
+		priority, preempts = getPriority(c, priorityClass)
+	}
 	undefinedCPUReq, undefinedCPULim, undefinedMemoryReq, undefinedMemoryLim := make([]string, 0, 3), make([]string, 0, 3), make([]string, 0, 3), make([]string, 0, 3)
 
 	// check for healthy nodes
 	for n := 0; n < len(nodes.Items); n++ {
-		for c := 0; c < len(nodes.Items[n].Status.Conditions); c++ {
-			conditionType := string(nodes.Items[n].Status.Conditions[c].Type)
-			conditions[conditionType] = string(nodes.Items[n].Status.Conditions[c].Status)
-		}
+		if isHealthy(&nodes.Items[n]) {
+			if isWorker(&nodes.Items[n]) {
+				node++
+				// a kept node runs the pods of the removed nodes placed on it as well, an added node
+				// runs nothing but the pods of the DaemonSets starting on it.
+				var nodePods []v1.Pod
+				if pods, ok := rehomed[nodes.Items[n].Name]; ok {
+					nodePods = pods
+				} else if simulated[nodes.Items[n].Name] == "added" {
+					nodePods = daemonSetPods(daemonSets, &nodes.Items[n])
+				} else {
+					nodePods = getNodePods(c, nodes.Items[n].Name, namespaceList)
+				}
 
-		/* A node is healthy if it is not under any pressure and posting a Ready status.
-		   Some of the status from Node Life cycle are;
-		   1. Disk pressure
-		   2. Memory Pressure
-		   3. Cpu pressure
-		*/
-		for key, value := range conditions {
-			if value == "False" && key != "Ready" {
-				temp = "ok"
-			} else if value == "True" && key == "Ready" {
-				temp = "ok"
-			} else {
-				temp = "bad"
-			}
-		}
+				// the pods of the exempt namespaces may be left out of the capacity math altogether.
+				if filter.excludeFromCapacity {
+					counted := make([]v1.Pod, 0, len(nodePods))
+					for _, p := range nodePods {
+						if !exempt[p.Namespace] {
+							counted = append(counted, p)
+						}
+					}
+					nodePods = counted
+				}
 
-		if temp == "ok" {
-			if nodes.Items[n].Labels["node-role.kubernetes.io/node"] == "true" {
-				node++
 				// get accumulated allocation of cpu and memory
-				cpuReq, cpuLimit, memoryReq, memoryLimit, totalPods, errorDict := calculatePodResources(c, nodes.Items[n].Name, namespaceList)
+				cpuReq, cpuLimit, memoryReq, memoryLimit, storageReq, storageLimit, totalPods, extendedReq, errorDict := calculatePodResources(nodePods, exempt)
 
 				cap := nodes.Items[n].Status.Capacity
 				alloc := nodes.Items[n].Status.Allocatable
@@ -186,29 +295,39 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 
 				nodeCPUAllocatable := alloc.Cpu().MilliValue()
 				nodeMemoryAllocatable := alloc.Memory().Value()
+				nodeStorageAllocatable := alloc.StorageEphemeral().Value()
+				extendedAllocatable := nodeExtendedAllocatable(alloc)
 
 				remainingCPUReq := nodeCPUAllocatable - cpuReq
-				TotalCPUAsk := cpuToInt64(cpuLimitAsk) + cpuLimit
-
-				cpuLimitAskPercentage := TotalCPUAsk / nodeCPUCapacity * 100
-
 				remainingMemoryReq := nodeMemoryAllocatable - memoryReq
-				TotalMemoryAsk := ToBytes(memoryLimitAsk) + memoryLimit
-
-				memoryLimitAskPercentage := TotalMemoryAsk / nodeMemoryCapacity * 100
+				remainingStorageReq := nodeStorageAllocatable - storageReq
+
+				// every replica binds the same host ports, so they limit the pods per node as well,
+				// a host port taken as a Service node port conflicts on every node.
+				portConflicts := append(hostPortConflicts(nodePods, hostPorts), nodePorts...)
+				portCap := hostPortCap(hostPorts, portConflicts, podAllocatable)
+				if len(portConflicts) > 0 {
+					portConflictNodes = append(portConflictNodes, fmt.Sprintf("%s: %s", shortName(nodes.Items[n].Name), strings.Join(portConflicts, ",")))
+				}
 
 				// print header for the first time and ensure, it doesn't repeat.
 				if !header {
-					if printHeader(w) {
+					if printHeader(w, extendedAsk.Names()) {
 						header = true
 					}
 				}
 
 				/* Once we get the number of spinable pods per node, we should
 				determine if the requested number of replicas be achieved in
-				the cluster, assuming there is no port constraint.
+				the cluster, host ports being the only port constraint.
 				*/
-				spinable, overcommittedNode := calculateCapacity(strings.SplitAfterN(nodes.Items[n].Name, ".", 2)[0],
+				// simulated nodes are marked, they are not part of the cluster as it is.
+				nodeName := shortName(nodes.Items[n].Name)
+				if simulated[nodes.Items[n].Name] != "" {
+					nodeName += "*"
+				}
+
+				spinable := calculateCapacity(nodeName,
 					nodeCPUCapacity,
 					nodeMemoryCapacity,
 					podCapacity,
@@ -219,42 +338,157 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 					cpuLimit,
 					memoryReq,
 					memoryLimit,
+					nodeStorageAllocatable,
+					storageReq,
+					storageLimit,
 					totalPods,
 					remainingCPUReq,
 					remainingMemoryReq,
+					remainingStorageReq,
 					cpuToInt64(cpuAsk),
 					ToBytes(memoryAsk),
+					ToBytes(storageAsk),
 					replicaAsk,
-					memoryLimitAskPercentage,
-					cpuLimitAskPercentage,
+					portCap,
+					extendedAllocatable,
+					extendedReq,
+					extendedAsk,
 					w)
 
 				netReplicas = netReplicas + spinable
 
-				if overcommittedNode != "nil" {
-					overcommittedNodes = append(overcommittedNodes, overcommittedNode)
+				// the replicas are placed on the nodes in order, as many as each node fits.
+				replicas := spinable
+				if left := int64(replicaAsk) - placed; replicas > left {
+					replicas = left
+				}
+				placed += replicas
+
+				// a node is overcommitted if its limits, along with the ones of the replicas placed on it,
+				// go past the threshold of its node pool. Nodes not reporting ephemeral storage can not be
+				// overcommitted on it.
+				threshold := thresholds.forNode(&nodes.Items[n])
+				projectedCPULimit := percentOf(cpuLimit+replicas*cpuToInt64(cpuLimitAsk), nodeCPUAllocatable)
+				projectedMemoryLimit := percentOf(memoryLimit+replicas*ToBytes(memoryLimitAsk), nodeMemoryAllocatable)
+				projectedStorageLimit := percentOf(storageLimit+replicas*ToBytes(storageLimitAsk), nodeStorageAllocatable)
+				if projectedCPULimit > threshold.cpu || projectedMemoryLimit > threshold.memory || projectedStorageLimit > 100 {
+					overcommittedNodes = append(overcommittedNodes, fmt.Sprintf("%s (cpu %.2f%%, memory %.2f%%, storage %.2f%%)", nodeName, projectedCPULimit, projectedMemoryLimit, projectedStorageLimit))
+				}
+
+				// each node takes its share of the cost of the replicas placed on it.
+				if prices != nil {
+					if instance, price, ok := prices.priceOf(&nodes.Items[n]); ok {
+						if pricedNode == nil {
+							pricedNode = &nodes.Items[n]
+						}
+						if replicas > 0 {
+							costs = append(costs, nodeCost{
+								node:         nodeName,
+								instanceType: instance,
+								price:        price,
+								replicas:     replicas,
+								share:        costShare(replicas, cpuToInt64(cpuAsk), ToBytes(memoryAsk), nodeCPUAllocatable, nodeMemoryAllocatable, price),
+							})
+						}
+					} else {
+						unpriced = append(unpriced, nodeName)
+					}
+				}
+
+				// keep the node taking the largest single pod in each of the dimensions.
+				nodeLargestCPU, nodeLargestMemory, nodeFreePods := largestPod(remainingCPUReq, remainingMemoryReq, podAllocatable-int64(totalPods))
+				if nodeLargestCPU > largestCPU {
+					largestCPU, largestCPUFit = nodeLargestCPU, fmt.Sprintf("%dm (%s)", nodeLargestCPU, shortName(nodes.Items[n].Name))
 				}
-				undefinedCPUReq, undefinedCPULim, undefinedMemoryReq, undefinedMemoryLim = errorDict["undefinedCPUReq"], errorDict["undefinedCPULim"], errorDict["undefinedMemoryReq"], errorDict["undefinedMemoryLim"]
+				if nodeLargestMemory > largestMemory {
+					largestMemory, largestMemoryFit = nodeLargestMemory, fmt.Sprintf("%s (%s)", FromBytes(nodeLargestMemory), shortName(nodes.Items[n].Name))
+				}
+				if nodeFreePods > largestPods {
+					largestPods, largestPodsFit = nodeFreePods, fmt.Sprintf("%d (%s)", nodeFreePods, shortName(nodes.Items[n].Name))
+				}
+
+				if defrag {
+					clusterNodes = append(clusterNodes, newClusterNode(&nodes.Items[n], nodePods))
+				}
+
+				if preempts {
+					preemptionCandidates = append(preemptionCandidates, preemptionCandidate{
+						node:                shortName(nodes.Items[n].Name),
+						cordoned:            nodes.Items[n].Spec.Unschedulable,
+						remainingCPUReq:     remainingCPUReq,
+						remainingMemoryReq:  remainingMemoryReq,
+						remainingStorageReq: remainingStorageReq,
+						freePods:            podAllocatable - int64(totalPods),
+						portCap:             portCap,
+						extendedAllocatable: extendedAllocatable,
+						extendedReq:         extendedReq,
+						victims:             preemptionVictims(nodePods, priority),
+					})
+				}
+
+				// account every extended resource the node advertises or the pods were asked for.
+				for name := range extendedAsk {
+					if _, ok := extendedAllocatable[name]; !ok {
+						extendedAllocatable[name] = 0
+					}
+				}
+				for _, name := range extendedAllocatable.Names() {
+					extendedAccounting = append(extendedAccounting, extendedUsage{
+						node:        shortName(nodes.Items[n].Name),
+						name:        name,
+						allocated:   extendedReq[name],
+						allocatable: extendedAllocatable[name],
+					})
+				}
+
+				// the warnings of every node are kept, not just the ones of the last.
+				undefinedCPUReq = append(undefinedCPUReq, errorDict["undefinedCPUReq"]...)
+				undefinedCPULim = append(undefinedCPULim, errorDict["undefinedCPULim"]...)
+				undefinedMemoryReq = append(undefinedMemoryReq, errorDict["undefinedMemoryReq"]...)
+				undefinedMemoryLim = append(undefinedMemoryLim, errorDict["undefinedMemoryLim"]...)
 			} else if nodes.Items[n].Labels["node-role.kubernetes.io/master"] == "true" || nodes.Items[n].Labels["node-role.kubernetes.io/master"] == "" {
 				master++
 			}
 		} else {
-			unHealthyNodes = append(unHealthyNodes, strings.SplitAfterN(nodes.Items[n].Name, ".", 2)[0])
+			unHealthyNodes = append(unHealthyNodes, shortName(nodes.Items[n].Name))
 		}
 	}
-	if len(undefinedCPUReq) > 0 {
-		errorredPods += len(undefinedCPUReq)
+	// a pod missing more than one of them is counted once.
+	erroredPodNames := make(map[string]bool)
+	for _, undefined := range [][]string{undefinedCPUReq, undefinedCPULim, undefinedMemoryReq, undefinedMemoryLim} {
+		for _, podName := range undefined {
+			erroredPodNames[podName] = true
+		}
 	}
-	if len(undefinedCPULim) > 0 {
-		errorredPods += len(undefinedCPULim)
+	errorredPods = len(erroredPodNames)
+	Columns(w, "\n")
+	if len(extendedAccounting) > 0 {
+		printExtendedAccounting(w, extendedAccounting)
 	}
-	if len(undefinedMemoryReq) > 0 {
-		errorredPods += len(undefinedMemoryReq)
+	if defrag {
+		printFragmentation(w, clusterNodes, cpuToInt64(cpuAsk), ToBytes(memoryAsk), int64(replicaAsk))
 	}
-	if len(undefinedMemoryLim) > 0 {
-		errorredPods += len(undefinedMemoryLim)
+	if prices != nil {
+		missing := int64(replicaAsk) - placed
+		additionalType, additionalNodes, additionalPrice := "", int64(0), float64(0)
+		if missing > 0 && pricedNode != nil {
+			// the nodes to add are taken to be like the first priced worker node, running the DaemonSets matching it.
+			if len(daemonSets) == 0 {
+				daemonSets = getDaemonSets(c)
+			}
+			additional := cloneNode(pricedNode, "priced-node", daemonSets)
+			remainingCPUReq, remainingMemoryReq, freePods := additional.remaining()
+			perNode := nodeFit(remainingCPUReq, remainingMemoryReq, cpuToInt64(cpuAsk), ToBytes(memoryAsk), freePods)
+			if portCap := hostPortCap(hostPorts, append(hostPortConflicts(additional.pods, hostPorts), nodePorts...), perNode); portCap < perNode {
+				perNode = portCap
+			}
+			if perNode > 0 {
+				additionalNodes = (missing + perNode - 1) / perNode
+				additionalType, additionalPrice, _ = prices.priceOf(pricedNode)
+			}
+		}
+		printCostEstimate(w, costs, unpriced, missing, additionalType, additionalNodes, additionalPrice)
 	}
-	Columns(w, "\n")
 	// if  there are no worker nodes, print a warning message. TODO: format this message properly.
 	if node == 0 {
 		fmt.Println(" W: Number of worker nodes are 0!!!")
@@ -262,20 +496,49 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 	}
 
 	Rows(w, "%s\t%d\t%s\t%d\n", "Number of Master Nodes: ", master, "Number of worker nodes: ", node)
+	if !edits.empty() {
+		simulatedNodes := make([]string, 0, len(simulated))
+		for name, change := range simulated {
+			simulatedNodes = append(simulatedNodes, fmt.Sprintf("%s (%s)", shortName(name), change))
+		}
+		sort.Strings(simulatedNodes)
+		Rows(w, "%s\t%d\t%s\t%d\n", "Simulated Nodes: ", len(simulated), "Removed Nodes: ", len(edits.remove))
+		Rows(w, "%s\t%s\t", "Simulated Nodes List: ", VPrint(simulatedNodes))
+		Rows(w, "%s\t%s\t", "Removed Nodes List: ", VPrint(edits.remove))
+		if len(removed) > 0 {
+			Rows(w, "%s\t%d\t\n", "Pods Of Removed Nodes Without Room: ", len(unplaced))
+			Rows(w, "%s\t%s\t", "Pods Without Room List: ", VPrint(unplaced))
+		}
+	}
 	Rows(w, "%s\t%s\t%s\t%s\n", "Memory Request via STDIN: ", memoryAsk, "Memory Limit via STDIN: ", memoryLimitAsk)
 	Rows(w, "%s\t%s\t%s\t%s\n", "CPU Request via STDIN: ", cpuAsk, "CPU Limit via STDIN: ", cpuLimitAsk)
+	Rows(w, "%s\t%s\t%s\t%s\n", "Storage Request via STDIN: ", storageAsk, "Storage Limit via STDIN: ", storageLimitAsk)
+	if len(extendedAsk) > 0 {
+		Rows(w, "%s\t%s\t\n", "Extended Resources via STDIN: ", extendedAsk.String())
+	}
 
 	if netReplicas >= int64(replicaAsk) {
 		Rows(w, "%s\t%d\t%s\t%s\n", "Replica Requested via STDIN: ", replicaAsk, "Is Scheduleable?: ", "True")
 	} else {
 		Rows(w, "%s\t%d\t%s\t%s\n", "Replica Requested via STDIN: ", replicaAsk, "Is Scheduleable?: ", "False")
 	}
+	Rows(w, "%s\t%s\t%s\t%s\n", "Largest CPU Fit: ", largestCPUFit, "Largest Memory Fit: ", largestMemoryFit)
+	Rows(w, "%s\t%s\t\n", "Most Free Pod Slots: ", largestPodsFit)
 
 	Columns(w, "\n")
+	if priorityClass != "" {
+		printPreemption(w, priorityClass, preempts, preemptionCandidates, cpuToInt64(cpuAsk), ToBytes(memoryAsk), ToBytes(storageAsk), extendedAsk, replicaAsk, netReplicas)
+	}
 	Rows(w, "%s\t%d\t\n", "Nodes With OverCommitted CPU/Memory: ", len(overcommittedNodes))
 	Rows(w, "%s\t%s\t", "Overcommitted Nodes List: ", VPrint(overcommittedNodes))
 	Columns(w, "\n")
 
+	if len(hostPorts) > 0 {
+		Rows(w, "%s\t%d\t\n", "Nodes With Host Port Conflicts: ", len(portConflictNodes))
+		Rows(w, "%s\t%s\t", "Host Port Conflicts List: ", VPrint(portConflictNodes))
+		Columns(w, "\n")
+	}
+
 	Rows(w, "%s\t%d\t\n", "Unhealthy Nodes: ", len(unHealthyNodes))
 
 	Rows(w, "%s\t%s\t", "Unhealthy Nodes List: ", VPrint(unHealthyNodes))
@@ -296,14 +559,12 @@ func getNodeResources(w *tabwriter.Writer, c *k8s.Clientset, cpuAsk string, memo
 	}
 	Columns(w, "\n")
 	w.Flush()
+	return netReplicas
 }
 
-// calculatePodResources calculates resources currently consumed by each pod.
-func calculatePodResources(c *k8s.Clientset, nodeName string, nsList []string) (int64, int64, int64, int64, int, map[string][]string) {
-
-	var podLength int
-	podLength = 0
-	errorMap := make(map[string][]string)
+// getNodePods lists the running pods of the node in all the namespaces.
+func getNodePods(c *k8s.Clientset, nodeName string, nsList []string) []v1.Pod {
+	nodePods := make([]v1.Pod, 0, 3)
 
 	// set condition to identify the non terminted pods, based on the Pods Life Cycle
 	fieldSelector, err := fields.ParseSelector("spec.nodeName=" + nodeName + ",status.phase!=" + "Pending" + ",status.phase!=" + "Succeeded" + ",status.phase!=" + "Failed" + ",status.phase!=" + "Unknown")
@@ -311,50 +572,69 @@ func calculatePodResources(c *k8s.Clientset, nodeName string, nsList []string) (
 		fmt.Println("There is a problem setting filters!!")
 		panic(err.Error())
 	}
-	// initialize the variables
-	request, reqlimit, cpureq, memoryreq, cpulimit, memorylimit := int64(0), int64(0), int64(0), int64(0), int64(0), int64(0)
 
-	// check all namespaces on individual nodes and loop through containers to get allocations at container level.
 	for s := 0; s < len(nsList); s++ {
 		pods, err := c.CoreV1().Pods(nsList[s]).List(metav1.ListOptions{FieldSelector: fieldSelector.String()})
 		if err != nil {
 			fmt.Println("There is a problem getting pods!!")
 			panic(err.Error())
 		}
-		for _, p := range pods.Items {
-			podName := string(p.Name)
-			for _, container := range p.Spec.Containers {
-				// get limits and requests and sum them up
-				request = container.Resources.Requests.Cpu().MilliValue()
-				memory := container.Resources.Requests.Memory().Value()
-				reqlimit = container.Resources.Limits.Cpu().MilliValue()
-				memlimit := container.Resources.Limits.Memory().Value()
-
-				if nsList[s] != "kube-system" && nsList[s] != "ingress-nginx" && nsList[s] != "kubernetes-dashboard" {
-					if request == 0 {
-						errorMap["undefinedCPUReq"] = append(errorMap["undefinedCPUReq"], podName)
-					}
-					if reqlimit == 0 {
-						errorMap["undefinedCPULim"] = append(errorMap["undefinedCPULim"], podName)
-					}
-					if memory == 0 {
-						errorMap["undefinedMemoryReq"] = append(errorMap["undefinedMemoryReq"], podName)
-					}
-					if memlimit == 0 {
-						errorMap["undefinedMemoryLim"] = append(errorMap["undefinedMemoryLim"], podName)
-					}
-				}
+		nodePods = append(nodePods, pods.Items...)
+	}
+	return nodePods
+}
+
+// calculatePodResources calculates resources currently consumed by each pod, the pods of the
+// exempt namespaces are not warned about undefined requests and limits. A pod is warned about
+// once per reason, however many of its containers leave it undefined.
+func calculatePodResources(nodePods []v1.Pod, exempt map[string]bool) (int64, int64, int64, int64, int64, int64, int, extendedResources, map[string][]string) {
+
+	var podLength int
+	podLength = 0
+	errorMap := make(map[string][]string)
+	extended := make(extendedResources)
 
-				cpureq += request
-				memoryreq += memory
-				cpulimit += reqlimit
-				memorylimit += memlimit
+	// initialize the variables
+	request, reqlimit, cpureq, memoryreq, cpulimit, memorylimit := int64(0), int64(0), int64(0), int64(0), int64(0), int64(0)
+	storagereq, storagelimit := int64(0), int64(0)
+
+	// loop through containers of the pods on individual nodes to get allocations at container level.
+	for _, p := range nodePods {
+		podName := podKey(&p)
+		undefined := make(map[string]bool)
+		for _, container := range p.Spec.Containers {
+			// get limits and requests and sum them up
+			request = container.Resources.Requests.Cpu().MilliValue()
+			memory := container.Resources.Requests.Memory().Value()
+			reqlimit = container.Resources.Limits.Cpu().MilliValue()
+			memlimit := container.Resources.Limits.Memory().Value()
+
+			if !exempt[p.Namespace] {
+				undefined["undefinedCPUReq"] = undefined["undefinedCPUReq"] || request == 0
+				undefined["undefinedCPULim"] = undefined["undefinedCPULim"] || reqlimit == 0
+				undefined["undefinedMemoryReq"] = undefined["undefinedMemoryReq"] || memory == 0
+				undefined["undefinedMemoryLim"] = undefined["undefinedMemoryLim"] || memlimit == 0
 			}
-			podLength++
+
+			cpureq += request
+			memoryreq += memory
+			cpulimit += reqlimit
+			memorylimit += memlimit
+			storagereq += container.Resources.Requests.StorageEphemeral().Value()
+			storagelimit += container.Resources.Limits.StorageEphemeral().Value()
+		}
+		for name, value := range podExtendedRequests(&p.Spec) {
+			extended[name] += value
 		}
+		for _, reason := range []string{"undefinedCPUReq", "undefinedCPULim", "undefinedMemoryReq", "undefinedMemoryLim"} {
+			if undefined[reason] {
+				errorMap[reason] = append(errorMap[reason], podName)
+			}
+		}
+		podLength++
 	}
 
-	return cpureq, cpulimit, memoryreq, memorylimit, podLength, errorMap
+	return cpureq, cpulimit, memoryreq, memorylimit, storagereq, storagelimit, podLength, extended, errorMap
 }
 
 // cpuToInt64 converts string data to integer to represents CPU units in milicores
@@ -421,6 +701,32 @@ func ToBytes(s string) int64 {
 	}
 }
 
+// percentOf returns the part in percent of the whole, zero if the whole is.
+func percentOf(part int64, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// FromBytes converts bytes to the string format ToBytes reads, in the largest unit dividing them evenly.
+func FromBytes(bytes int64) string {
+	switch {
+	case bytes == 0:
+		return "0B"
+	case bytes%TERABYTE == 0:
+		return fmt.Sprintf("%dT", bytes/TERABYTE)
+	case bytes%GIGABYTE == 0:
+		return fmt.Sprintf("%dG", bytes/GIGABYTE)
+	case bytes%MEGABYTE == 0:
+		return fmt.Sprintf("%dM", bytes/MEGABYTE)
+	case bytes%KILOBYTE == 0:
+		return fmt.Sprintf("%dK", bytes/KILOBYTE)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
 // calculateCapacity calculates current usage and maximum number of spinable pods per node and return
 func calculateCapacity(node string,
 	nodeCPUCapacity int64,
@@ -433,34 +739,71 @@ func calculateCapacity(node string,
 	cpuLimit int64,
 	memoryReq int64,
 	memoryLimit int64,
+	nodeStorageAllocatable int64,
+	storageReq int64,
+	storageLimit int64,
 	totalPods int,
 	remainingCPUReq int64,
 	remainingMemoryReq int64,
+	remainingStorageReq int64,
 	cpuAsk int64,
 	memoryAsk int64,
+	storageAsk int64,
 	replicaAsk int,
-	memoryLimitAskPercentage int64,
-	cpuLimitAskPercentage int64,
-	p *tabwriter.Writer) (int64, string) {
+	portCap int64,
+	extendedAllocatable extendedResources,
+	extendedReq extendedResources,
+	extendedAsk extendedResources,
+	p *tabwriter.Writer) int64 {
 
 	fractionNODECPUReq := float64(cpuReq) / float64(nodeCPUAllocatable) * 100
 	fractionNodeMemoryReq := float64(memoryReq) / float64(nodeMemoryAllocatable) * 100
 	fractionNODECPULimit := float64(cpuLimit) / float64(nodeCPUAllocatable) * 100
 	fractionNodeMemoryLimit := float64(memoryLimit) / float64(nodeMemoryAllocatable) * 100
+	fractionNodeStorageReq := float64(0)
+	if nodeStorageAllocatable > 0 {
+		fractionNodeStorageReq = float64(storageReq) / float64(nodeStorageAllocatable) * 100
+	}
 
-	spinable, cpuCrunch, memoryCrunch := Isspinable(remainingCPUReq, remainingMemoryReq, cpuAsk, memoryAsk, replicaAsk, podAllocatable)
+	// nodeFit leaves out the resources the replicas ask none of, the crunch tells if a single
+	// replica does not find what it asks for. The pods running take their pod slots, the same
+	// way the preemption plan counts them.
+	freePods := podAllocatable - int64(totalPods)
+	spinable := nodeFit(remainingCPUReq, remainingMemoryReq, cpuAsk, memoryAsk, freePods)
+	cpuCrunch, memoryCrunch := cpuAsk > 0 && remainingCPUReq < cpuAsk, memoryAsk > 0 && remainingMemoryReq < memoryAsk
+
+	// ephemeral storage is asked for only when given via STDIN, a node short of it can not take a single pod.
+	storageCrunch := false
+	if storageAsk > 0 {
+		storageFit := remainingStorageReq / storageAsk
+		if storageFit < 1 {
+			storageFit = 0
+			storageCrunch = true
+		}
+		if storageFit < spinable {
+			spinable = storageFit
+		}
+	}
 
-	if spinable == podAllocatable {
-		spinable = spinable - int64(totalPods)
+	if portCap < spinable {
+		spinable = portCap
 	}
 
-	Rows(p, "%2s\t%4.2f%%\t%4.2f%%\t%6.2f%%\t%7.2f%%\t%5d\t%11t\t%10t\t%6d\t\n", node, fractionNODECPUReq, fractionNodeMemoryReq, fractionNODECPULimit, fractionNodeMemoryLimit, totalPods, cpuCrunch, memoryCrunch, spinable)
+	// extended resources are one more constraint, the one fitting the least pods binds.
+	extendedFit, extendedCrunch := extendedSpinable(extendedAllocatable, extendedReq, extendedAsk, freePods)
+	if extendedFit < spinable {
+		spinable = extendedFit
+	}
 
-	if memoryLimitAskPercentage > 110 || cpuLimitAskPercentage > 100 || int64(fractionNODECPULimit) > 110 || int64(fractionNodeMemoryLimit) > 100 {
-		return spinable, node
+	Rows(p, "%2s\t%4.2f%%\t%4.2f%%\t%6.2f%%\t%7.2f%%\t%9.2f%%\t%11dM\t%5d\t%11t\t%10t\t%14t\t", node, fractionNODECPUReq, fractionNodeMemoryReq, fractionNODECPULimit, fractionNodeMemoryLimit, fractionNodeStorageReq, nodeStorageAllocatable/MEGABYTE, totalPods, cpuCrunch, memoryCrunch, storageCrunch)
+	for _, name := range extendedAsk.Names() {
+		Rows(p, "%10t\t", extendedCrunch[name])
 	}
+	// the largest single pod the node still accepts tells how fragmented the free resources are.
+	largestCPU, largestMemory, freePods := largestPod(remainingCPUReq, remainingMemoryReq, freePods)
+	Rows(p, "%6d\t%9dm\t%9dM\t%8d\t\n", spinable, largestCPU, largestMemory/MEGABYTE, freePods)
 
-	return spinable, "nil"
+	return spinable
 }
 
 // Isspinable Test how many more pods can be spun with same resources given, per node.
@@ -497,6 +840,35 @@ func Isspinable(remainingCPUReq int64, remainingMemoryReq int64, cpuAsk int64, m
 	return 0, true, true
 }
 
+// isHealthy tells if the node is healthy.
+func isHealthy(node *v1.Node) bool {
+
+	/* A node is healthy if it is not under any pressure and posting a Ready status.
+	   Some of the status from Node Life cycle are;
+	   1. Disk pressure
+	   2. Memory Pressure
+	   3. Cpu pressure
+	*/
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady && condition.Status != v1.ConditionTrue {
+			return false
+		} else if condition.Type != v1.NodeReady && condition.Status != v1.ConditionFalse {
+			return false
+		}
+	}
+	return true
+}
+
+// isWorker tells if the node is labeled as a worker node.
+func isWorker(node *v1.Node) bool {
+	return node.Labels["node-role.kubernetes.io/node"] == "true"
+}
+
+// shortName returns the node name without the domain.
+func shortName(name string) string {
+	return strings.SplitAfterN(name, ".", 2)[0]
+}
+
 // getKubeConfig sets the path of kubeconfg file.
 func getKubeConfig() string {
 	// try read config file from environment.
@@ -509,6 +881,37 @@ func getKubeConfig() string {
 	}
 }
 
+// kubeConfigFlag declares the kubeconfig switch on the flag set, defaulting it to the path from getKubeConfig.
+func kubeConfigFlag(f *flag.FlagSet) *string {
+	if kubeConfigFile := getKubeConfig(); kubeConfigFile != "" {
+		return f.String("kubeconfig", kubeConfigFile, "(optional) absolute path to the kubeconfig file")
+	}
+	return f.String("kubeconfig", "", "absolute path to the kubeconfig file")
+}
+
+// newClientSet creates a kubernetes client out of the kubeconfig file.
+func newClientSet(kubeconfig string) *k8s.Clientset {
+	loadConfig, err := cmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		fmt.Println("There is a problem loading kubeconfig file!!")
+		panic(err.Error())
+	}
+
+	clientSet, err := k8s.NewForConfig(loadConfig)
+	if err != nil {
+		fmt.Println("There is a problem creating a client!!")
+		panic(err.Error())
+	}
+	return clientSet
+}
+
+// newTabWriter initializes tabwriter for formatted printing.
+func newTabWriter() *tabwriter.Writer {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 6, 3, ' ', tabwriter.AlignRight)
+	return w
+}
+
 // Columns create columns for the output table.
 func Columns(p *tabwriter.Writer, out string) {
 
@@ -522,12 +925,16 @@ func Rows(p *tabwriter.Writer, format string, out ...interface{}) {
 }
 
 // printHeader prints the formatted header for the output from this program.
-func printHeader(p *tabwriter.Writer) bool {
+func printHeader(p *tabwriter.Writer, extendedNames []string) bool {
 	Columns(p, "\n")
 	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
 	Rows(p, "%40s\t%50s\n", "Current Capacity Usage Per Node", "spinable Pods")
 	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
-	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s |\t%-5s\t%-5s\t%-5s\t\n", "Node", "CpuReq", "MemReq", "CpuLimit", "MemLimit", "Pods", "CpuCrunch", "MemCrunch", "spinable")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s |\t%-5s\t%-5s\t%-5s\t", "Node", "CpuReq", "MemReq", "CpuLimit", "MemLimit", "StorageReq", "StorageAlloc", "Pods", "CpuCrunch", "MemCrunch", "StorageCrunch")
+	for _, name := range extendedNames {
+		Rows(p, "%-5s\t", crunchLabel(name))
+	}
+	Rows(p, "%-5s\t%-5s\t%-5s\t%-5s\t\n", "spinable", "LargestCpu", "LargestMem", "FreePods")
 	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
 	p.Flush()
 
@@ -557,24 +964,70 @@ func printLegends(p *tabwriter.Writer) {
 	Rows(p, "%s\t\n", "+++++++ Legends +++++++")
 	Columns(p, "\n")
 	Rows(p, "%s\t%s\n", "Is Scheduleable?: ", "if 'true', Pods can be spun on worker node with the amount of CPU and Memory requested. False, otherwise.")
-	Rows(p, "%s\t%s\n", "Overcommitted Nodes List: ", "List of nodes which will OverCommit CPu/Memory Limits with the amount of CPU and Memory requested.")
+	Rows(p, "%s\t%s\n", "Overcommitted Nodes List: ", "List of nodes whose CPU/Memory/Storage Limits, along with the limits of the replicas placed on them, go past the -overcommit threshold of their node pool.")
+	Rows(p, "%s\t%s\n", "Largest CPU Fit: ", "largest CPU request a single pod can have and still be spun on any of the worker nodes, along with the node.")
+	Rows(p, "%s\t%s\n", "Largest Memory Fit: ", "largest Memory request a single pod can have and still be spun on any of the worker nodes, along with the node.")
+	Rows(p, "%s\t%s\n", "Fits With Preemption: ", "number of replicas that can be spun once the pods with lower priority than the priority class are preempted.")
+	Rows(p, "%s\t%s\n", "Preempted Pods List: ", "List of pods which would be preempted to spin the replicas requested, along with their node and priority.")
+	Rows(p, "%s\t%s\n", "Host Port Conflicts List: ", "List of nodes where pods already bind the host ports of the workload, along with the port and the pod holding it.")
+	Rows(p, "%s\t%s\n", "Simulated Nodes List: ", "List of nodes added or resized by the what-if switches, the removed ones are listed in Removed Nodes List.")
 	Rows(p, "%s\t%s\n", "Unhealthy Nodes List: ", "List of nodes which are not healthy or which reached either disk/memory/cpu load.")
 	Columns(p, "\n")
 	Rows(p, "%s\t\n", "Understanding spinable Pods")
 	Rows(p, "%-2s\n", "  +----------------------------------------------+")
 	Rows(p, "%s\t%s\n", "CpuCrunch: ", "if 'true', amount of CPU requested is not available on the worker node.")
 	Rows(p, "%s\t%s\n", "MemCrunch: ", "if 'true', amount of Memory requested is not available on the worker node.")
+	Rows(p, "%s\t%s\n", "StorageCrunch: ", "if 'true', amount of Ephemeral Storage requested is not available on the worker node.")
+	Rows(p, "%s\t%s\n", "<resource>Crunch: ", "if 'true', amount of the extended resource requested, e.g. gpuCrunch for nvidia.com/gpu, is not available on the worker node.")
 	Rows(p, "%s\t%s\n", "spinable: ", "maximum number of pods that can be spun on worker node with the amount of CPU and Memory requested.")
+	Rows(p, "%s\t%s\n", "LargestCpu: ", "largest CPU request a single pod can have and still be spun on worker node.")
+	Rows(p, "%s\t%s\n", "LargestMem: ", "largest Memory request a single pod can have and still be spun on worker node.")
+	Rows(p, "%s\t%s\n", "FreePods: ", "number of pods worker node can take before running out of pod slots.")
 	Columns(p, "\n")
 	p.Flush()
 	Rows(p, "%s\t\n", "Understanding Current Capacity Usage Per Node")
 	Rows(p, "%-2s\n", "  +----------------------------------------------+")
 	Rows(p, "%s\t%s\n", "Pods: ", "total number of pods currently running on worker node.")
 	Rows(p, "%s\t%s\n", "Nodes: ", "kubernetes cluster worker node names.")
+	Rows(p, "%s\t%s\n", "Nodes*: ", "simulated worker node, added by -add-node or resized by -resize-node, it is not part of the cluster.")
 	Rows(p, "%s\t%s\n", "CpuReq: ", "amount of CPU allocated on worker node, at present.")
 	Rows(p, "%s\t%s\n", "MemReq: ", "amount of Memory allocated on worker node, at present.")
 	Rows(p, "%s\t%s\n", "CpuLimit: ", "amount of CPU Limit set on worker node, at present.")
 	Rows(p, "%s\t%s\n", "MemLimit: ", "amount of Memory Limit set on worker node, at present.")
+	Rows(p, "%s\t%s\n", "StorageReq: ", "amount of Ephemeral Storage allocated on worker node, at present.")
+	Rows(p, "%s\t%s\n", "StorageAlloc: ", "amount of Ephemeral Storage allocatable on worker node, in MB.")
+	Columns(p, "\n")
+	p.Flush()
+	Rows(p, "%s\t\n", "Understanding Extended Resources Per Node")
+	Rows(p, "%-2s\n", "  +----------------------------------------------+")
+	Rows(p, "%s\t%s\n", "Allocated: ", "amount of the extended resource requested by pods on worker node, at present.")
+	Rows(p, "%s\t%s\n", "Allocatable: ", "amount of the extended resource worker node advertises for pods.")
+	Columns(p, "\n")
+	p.Flush()
+	Rows(p, "%s\t\n", "Understanding Fragmentation Per Node")
+	Rows(p, "%-2s\n", "  +----------------------------------------------+")
+	Rows(p, "%s\t%s\n", "StrandedCpu: ", "free CPU on worker node which is of no use, since the Memory requested is not available.")
+	Rows(p, "%s\t%s\n", "StrandedMem: ", "free Memory on worker node which is of no use, since the CPU requested is not available.")
+	Rows(p, "%s\t%s\n", "FragCpu: ", "free CPU on worker node left over in chunks smaller than the CPU requested.")
+	Rows(p, "%s\t%s\n", "FragMem: ", "free Memory on worker node left over in chunks smaller than the Memory requested.")
+	Rows(p, "%s\t%s\n", "Defragmentation Moves: ", "ReplicaSet pods to evict and where they would be rescheduled, to make room for the replicas requested.")
+	Columns(p, "\n")
+	p.Flush()
+	Rows(p, "%s\t\n", "Understanding Cost Estimate")
+	Rows(p, "%-2s\n", "  +----------------------------------------------+")
+	Rows(p, "%s\t%s\n", "InstanceType: ", "instance type of worker node, from its node.kubernetes.io/instance-type label.")
+	Rows(p, "%s\t%s\n", "Replicas: ", "number of the replicas requested placed on worker node, the nodes are filled in order.")
+	Rows(p, "%s\t%s\n", "HourlyShare: ", "part of the hourly price of worker node the replicas take, the larger of the CPU and Memory fractions of its allocatable.")
+	Columns(p, "\n")
+	p.Flush()
+	Rows(p, "%s\t\n", "Understanding Scale-Up Plan")
+	Rows(p, "%-2s\n", "  +----------------------------------------------+")
+	Rows(p, "%s\t%s\n", "DsCpu: ", "amount of CPU requested by the DaemonSets which would start on a new node of the template.")
+	Rows(p, "%s\t%s\n", "DsMem: ", "amount of Memory requested by the DaemonSets which would start on a new node of the template.")
+	Rows(p, "%s\t%s\n", "DsPods: ", "number of DaemonSet pods which would start on a new node of the template.")
+	Rows(p, "%s\t%s\n", "PerNode: ", "number of the replicas left out a new node of the template fits, after its DaemonSet pods.")
+	Rows(p, "%s\t%s\n", "NodesNeeded: ", "number of new nodes of the template needed to fit all of the replicas left out.")
+	Rows(p, "%s\t%s\n", "Unpriced Templates List: ", "templates of the catalog without a price, they are left out of the cheapest mix.")
 	p.Flush()
 
 	os.Exit(0)
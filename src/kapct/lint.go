@@ -0,0 +1,375 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// the severities of the lint findings, a failing finding fails the lint.
+const (
+	severityWarning = "warning"
+	severityError   = "error"
+)
+
+// lintRule is a resource hygiene rule checked against every container.
+type lintRule struct {
+	name     string
+	severity string
+	message  string
+}
+
+// lintRules are the rules kapct lint checks, the undefined requests are errors since the
+// scheduler and the capacity math count them as zero.
+var lintRules = []lintRule{
+	{name: "cpu-request-missing", severity: severityError, message: "CPU Requests must be defined"},
+	{name: "memory-request-missing", severity: severityError, message: "Memory Requests must be defined"},
+	{name: "cpu-limit-missing", severity: severityWarning, message: "CPU Limits must be defined"},
+	{name: "memory-limit-missing", severity: severityWarning, message: "Memory Limits must be defined"},
+	{name: "cpu-limit-ratio", severity: severityWarning, message: "CPU Limits exceed the Requests by more than the max ratio"},
+	{name: "memory-limit-ratio", severity: severityWarning, message: "Memory Limits exceed the Requests by more than the max ratio"},
+}
+
+// workloadContainer is a container of a workload, the findings are aggregated by it.
+type workloadContainer struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+}
+
+// lintFinding is a rule a container of a workload breaks, along with the pods it breaks it in.
+type lintFinding struct {
+	workloadContainer
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Pods     int    `json:"pods"`
+}
+
+// lintReport holds the containers checked and the findings, sorted by workload and container.
+type lintReport struct {
+	Checked  []workloadContainer `json:"checked"`
+	Findings []lintFinding       `json:"findings"`
+	Failed   bool                `json:"failed"`
+}
+
+// lint walks all the running pods and checks their containers against the resource hygiene
+// rules. The findings are aggregated by the owning workload and the container name, so a
+// Deployment of fifty replicas is reported once. It exits non-zero on failing findings,
+// for a CI job to fail on.
+func lint(args []string) {
+	var output string
+	var maxRatio float64
+	var failOn string
+
+	f := flag.NewFlagSet("lint", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&output, "o", "text", "output format of the report, text, json or junit.")
+	f.Float64Var(&maxRatio, "max-ratio", 4, "ratio of limits to requests above which a container breaks the ratio rules.")
+	f.StringVar(&failOn, "fail-on", severityError, "least severity failing the lint, error or warning.")
+	namespaceSwitches := namespaceFlags(f, false)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct lint [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if output != "text" && output != "json" && output != "junit" {
+		fmt.Fprintf(f.Output(), "%q is not a valid output format!!\n", output)
+		f.Usage()
+		os.Exit(2)
+	}
+	if failOn != severityError && failOn != severityWarning {
+		fmt.Fprintf(f.Output(), "%q is not a valid severity!!\n", failOn)
+		f.Usage()
+		os.Exit(2)
+	}
+
+	// the exempt namespaces are left out of the lint, the way they are not warned about elsewhere.
+	filter, err := namespaceSwitches.filter()
+	if err != nil {
+		fmt.Println("There is a problem parsing exempt-selector!!")
+		panic(err.Error())
+	}
+
+	c := newClientSet(*kubeconfig)
+	namespaces, err := c.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting namespaces!!")
+		panic(err.Error())
+	}
+	exempt := filter.exemptNamespaces(namespaces.Items)
+
+	// the pending pods are left out, their containers are checked once they run.
+	pods, err := c.CoreV1().Pods("").List(metav1.ListOptions{FieldSelector: "status.phase=" + string(v1.PodRunning)})
+	if err != nil {
+		fmt.Println("There is a problem getting pods!!")
+		panic(err.Error())
+	}
+
+	counted := make([]v1.Pod, 0, len(pods.Items))
+	for _, p := range pods.Items {
+		if !exempt[p.Namespace] {
+			counted = append(counted, p)
+		}
+	}
+
+	report := lintPods(counted, getWorkloadOwners(c, ""), maxRatio, failOn)
+	switch output {
+	case "json":
+		err = writeLintJSON(os.Stdout, report)
+	case "junit":
+		err = writeLintJUnit(os.Stdout, report, failOn)
+	default:
+		printLint(newTabWriter(), report, failOn)
+	}
+	if err != nil {
+		fmt.Println("There is a problem writing lint report!!")
+		panic(err.Error())
+	}
+
+	if report.Failed {
+		os.Exit(1)
+	}
+}
+
+// getWorkloadOwners returns the controllers of the ReplicaSets and the Jobs of the namespace, of
+// all the namespaces if empty, by their namespace/kind/name, that is the Deployments and the
+// CronJobs owning the pods through them.
+func getWorkloadOwners(c *k8s.Clientset, namespace string) map[string]metav1.OwnerReference {
+	owners := make(map[string]metav1.OwnerReference)
+
+	replicaSets, err := c.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting replicasets!!")
+		panic(err.Error())
+	}
+	for i := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&replicaSets.Items[i]); owner != nil {
+			owners[replicaSets.Items[i].Namespace+"/ReplicaSet/"+replicaSets.Items[i].Name] = *owner
+		}
+	}
+
+	jobs, err := c.BatchV1().Jobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting jobs!!")
+		panic(err.Error())
+	}
+	for i := range jobs.Items {
+		if owner := metav1.GetControllerOf(&jobs.Items[i]); owner != nil {
+			owners[jobs.Items[i].Namespace+"/Job/"+jobs.Items[i].Name] = *owner
+		}
+	}
+	return owners
+}
+
+// owningWorkload returns the kind and the name of the workload owning the pod, following a
+// ReplicaSet up to its Deployment and a Job up to its CronJob. A pod without a controller
+// owns itself.
+func owningWorkload(p *v1.Pod, owners map[string]metav1.OwnerReference) (string, string) {
+	owner := metav1.GetControllerOf(p)
+	if owner == nil {
+		return "Pod", p.Name
+	}
+	if parent, ok := owners[p.Namespace+"/"+owner.Kind+"/"+owner.Name]; ok {
+		return parent.Kind, parent.Name
+	}
+	return owner.Kind, owner.Name
+}
+
+// brokenRules returns the rules the container breaks.
+func brokenRules(resources v1.ResourceRequirements, maxRatio float64) []lintRule {
+	cpuReq, cpuLimit := resources.Requests.Cpu().MilliValue(), resources.Limits.Cpu().MilliValue()
+	memoryReq, memoryLimit := resources.Requests.Memory().Value(), resources.Limits.Memory().Value()
+
+	broken := make([]lintRule, 0, 2)
+	for _, rule := range lintRules {
+		var breaks bool
+		switch rule.name {
+		case "cpu-request-missing":
+			breaks = cpuReq == 0
+		case "memory-request-missing":
+			breaks = memoryReq == 0
+		case "cpu-limit-missing":
+			breaks = cpuLimit == 0
+		case "memory-limit-missing":
+			breaks = memoryLimit == 0
+		case "cpu-limit-ratio":
+			breaks = cpuReq > 0 && float64(cpuLimit) > maxRatio*float64(cpuReq)
+		case "memory-limit-ratio":
+			breaks = memoryReq > 0 && float64(memoryLimit) > maxRatio*float64(memoryReq)
+		}
+		if breaks {
+			broken = append(broken, rule)
+		}
+	}
+	return broken
+}
+
+// failing tells if the severity fails the lint.
+func failing(severity string, failOn string) bool {
+	return severity == severityError || failOn == severityWarning
+}
+
+// lintPods checks the containers of the pods, their init containers as well, and aggregates the
+// findings by workload and container.
+func lintPods(pods []v1.Pod, owners map[string]metav1.OwnerReference, maxRatio float64, failOn string) lintReport {
+	checked := make(map[workloadContainer]bool)
+	findings := make(map[string]*lintFinding)
+
+	for i := range pods {
+		p := &pods[i]
+		kind, name := owningWorkload(p, owners)
+		containers := append(append([]v1.Container(nil), p.Spec.InitContainers...), p.Spec.Containers...)
+		for _, container := range containers {
+			key := workloadContainer{Namespace: p.Namespace, Kind: kind, Workload: name, Container: container.Name}
+			checked[key] = true
+			for _, rule := range brokenRules(container.Resources, maxRatio) {
+				id := fmt.Sprintf("%s/%s/%s/%s/%s", key.Namespace, key.Kind, key.Workload, key.Container, rule.name)
+				finding, ok := findings[id]
+				if !ok {
+					finding = &lintFinding{workloadContainer: key, Rule: rule.name, Severity: rule.severity, Message: rule.message}
+					findings[id] = finding
+				}
+				finding.Pods++
+			}
+		}
+	}
+
+	report := lintReport{Checked: make([]workloadContainer, 0, len(checked)), Findings: make([]lintFinding, 0, len(findings))}
+	for key := range checked {
+		report.Checked = append(report.Checked, key)
+	}
+	sort.Slice(report.Checked, func(i, j int) bool { return lessContainer(report.Checked[i], report.Checked[j]) })
+
+	for _, finding := range findings {
+		report.Findings = append(report.Findings, *finding)
+		report.Failed = report.Failed || failing(finding.Severity, failOn)
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		if report.Findings[i].workloadContainer != report.Findings[j].workloadContainer {
+			return lessContainer(report.Findings[i].workloadContainer, report.Findings[j].workloadContainer)
+		}
+		return report.Findings[i].Rule < report.Findings[j].Rule
+	})
+	return report
+}
+
+// lessContainer orders the workload containers by namespace, kind, workload and container name.
+func lessContainer(a workloadContainer, b workloadContainer) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Workload != b.Workload {
+		return a.Workload < b.Workload
+	}
+	return a.Container < b.Container
+}
+
+// printLint prints the findings per workload container, along with how many of them fail the lint.
+func printLint(p *tabwriter.Writer, report lintReport, failOn string) {
+	Columns(p, "\n")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Resource Lint Per Workload")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Workload", "Container", "Rule", "Severity", "Pods")
+
+	errors, warnings := 0, 0
+	for _, finding := range report.Findings {
+		Rows(p, "%2s\t%s\t%s\t%s\t%4d\t\n", finding.Namespace+"/"+finding.Kind+"/"+finding.Workload, finding.Container, finding.Rule, finding.Severity, finding.Pods)
+		if finding.Severity == severityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	Columns(p, "\n")
+
+	Rows(p, "%s\t%d\t%s\t%d\n", "Containers Checked: ", len(report.Checked), "Findings: ", len(report.Findings))
+	Rows(p, "%s\t%d\t%s\t%d\n", "Errors: ", errors, "Warnings: ", warnings)
+	if report.Failed {
+		Rows(p, "%s\t%s\t%s\t%s\n", "Fail On: ", failOn, "Passed?: ", "False")
+	} else {
+		Rows(p, "%s\t%s\t%s\t%s\n", "Fail On: ", failOn, "Passed?: ", "True")
+	}
+	Columns(p, "\n")
+	p.Flush()
+}
+
+// writeLintJSON writes the report as JSON.
+func writeLintJSON(o io.Writer, report lintReport) error {
+	out := json.NewEncoder(o)
+	out.SetIndent("", "  ")
+	return out.Encode(report)
+}
+
+// junitSuite, junitCase and junitFailure make up a JUnit XML report, as CI servers read it.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+}
+
+// writeLintJUnit writes the report as JUnit XML, a test case per workload container. The
+// failing findings are its failures, the rest go to its output.
+func writeLintJUnit(o io.Writer, report lintReport, failOn string) error {
+	suite := junitSuite{Name: "kapct-lint", Tests: len(report.Checked)}
+	cases := make(map[workloadContainer]*junitCase, len(report.Checked))
+	for _, key := range report.Checked {
+		suite.Cases = append(suite.Cases, junitCase{Name: key.Container, ClassName: key.Namespace + "/" + key.Kind + "/" + key.Workload})
+	}
+	for i, key := range report.Checked {
+		cases[key] = &suite.Cases[i]
+	}
+
+	for _, finding := range report.Findings {
+		testCase := cases[finding.workloadContainer]
+		message := fmt.Sprintf("%s (%d pods)", finding.Message, finding.Pods)
+		if failing(finding.Severity, failOn) {
+			if len(testCase.Failures) == 0 {
+				suite.Failures++
+			}
+			testCase.Failures = append(testCase.Failures, junitFailure{Type: finding.Rule, Message: message})
+		} else {
+			testCase.SystemOut += fmt.Sprintf("%s: %s: %s\n", finding.Severity, finding.Rule, message)
+		}
+	}
+
+	if _, err := io.WriteString(o, xml.Header); err != nil {
+		return err
+	}
+	out := xml.NewEncoder(o)
+	out.Indent("", "  ")
+	if err := out.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(o, "\n")
+	return err
+}
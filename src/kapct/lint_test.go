@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lintContainer returns a container with the requests and limits given, nil for none.
+func lintContainer(name string, requests v1.ResourceList, limits v1.ResourceList) v1.Container {
+	return v1.Container{Name: name, Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}
+}
+
+func TestBrokenRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources v1.ResourceRequirements
+		broken    []string
+	}{
+		{
+			name:      "requests and limits defined",
+			resources: v1.ResourceRequirements{Requests: resources("100m", "128Mi"), Limits: resources("200m", "256Mi")},
+			broken:    []string{},
+		},
+		{
+			name:   "nothing defined",
+			broken: []string{"cpu-request-missing", "memory-request-missing", "cpu-limit-missing", "memory-limit-missing"},
+		},
+		{
+			name:      "limits only",
+			resources: v1.ResourceRequirements{Limits: resources("200m", "256Mi")},
+			broken:    []string{"cpu-request-missing", "memory-request-missing"},
+		},
+		{
+			name:      "limits past the max ratio",
+			resources: v1.ResourceRequirements{Requests: resources("100m", "128Mi"), Limits: resources("500m", "512Mi")},
+			broken:    []string{"cpu-limit-ratio"},
+		},
+		{
+			name:      "limits past the max ratio in both",
+			resources: v1.ResourceRequirements{Requests: resources("100m", "64Mi"), Limits: resources("1", "1Gi")},
+			broken:    []string{"cpu-limit-ratio", "memory-limit-ratio"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := make([]string, 0, len(tt.broken))
+			for _, rule := range brokenRules(tt.resources, 4) {
+				broken = append(broken, rule.name)
+			}
+			if !reflect.DeepEqual(broken, tt.broken) {
+				t.Errorf("broken rules = %v, want %v", broken, tt.broken)
+			}
+		})
+	}
+}
+
+func TestFailing(t *testing.T) {
+	tests := []struct {
+		severity string
+		failOn   string
+		failing  bool
+	}{
+		{severity: severityError, failOn: severityError, failing: true},
+		{severity: severityWarning, failOn: severityError, failing: false},
+		{severity: severityError, failOn: severityWarning, failing: true},
+		{severity: severityWarning, failOn: severityWarning, failing: true},
+	}
+
+	for _, tt := range tests {
+		if failing := failing(tt.severity, tt.failOn); failing != tt.failing {
+			t.Errorf("failing(%s, %s) = %t, want %t", tt.severity, tt.failOn, failing, tt.failing)
+		}
+	}
+}
+
+func TestLintPods(t *testing.T) {
+	defined := lintContainer("app", resources("100m", "128Mi"), resources("200m", "256Mi"))
+	noLimits := lintContainer("app", resources("100m", "128Mi"), nil)
+
+	web1 := testPod("shop", "web-1", "web-6d4f")
+	web1.Spec.Containers = []v1.Container{lintContainer("app", nil, resources("200m", "256Mi"))}
+	web2 := testPod("shop", "web-2", "web-6d4f")
+	web2.Spec.Containers = []v1.Container{lintContainer("app", nil, resources("200m", "256Mi"))}
+	migrate := testPod("shop", "api-1", "api-7c9b")
+	migrate.Spec.InitContainers = []v1.Container{lintContainer("migrate", nil, nil)}
+	migrate.Spec.Containers = []v1.Container{defined}
+	bare := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "tools", Name: "debug"}, Spec: v1.PodSpec{Containers: []v1.Container{noLimits}}}
+
+	// the ReplicaSets web-6d4f and api-7c9b are owned by Deployments, the findings go by those.
+	owners := map[string]metav1.OwnerReference{
+		"shop/ReplicaSet/web-6d4f": {Kind: "Deployment", Name: "web"},
+		"shop/ReplicaSet/api-7c9b": {Kind: "Deployment", Name: "api"},
+	}
+	pods := []v1.Pod{web1, web2, migrate, bare}
+
+	wantChecked := []workloadContainer{
+		{Namespace: "shop", Kind: "Deployment", Workload: "api", Container: "app"},
+		{Namespace: "shop", Kind: "Deployment", Workload: "api", Container: "migrate"},
+		{Namespace: "shop", Kind: "Deployment", Workload: "web", Container: "app"},
+		{Namespace: "tools", Kind: "Pod", Workload: "debug", Container: "app"},
+	}
+	migrateKey, webKey, debugKey := wantChecked[1], wantChecked[2], wantChecked[3]
+	wantFindings := []lintFinding{
+		{workloadContainer: migrateKey, Rule: "cpu-limit-missing", Severity: severityWarning, Message: "CPU Limits must be defined", Pods: 1},
+		{workloadContainer: migrateKey, Rule: "cpu-request-missing", Severity: severityError, Message: "CPU Requests must be defined", Pods: 1},
+		{workloadContainer: migrateKey, Rule: "memory-limit-missing", Severity: severityWarning, Message: "Memory Limits must be defined", Pods: 1},
+		{workloadContainer: migrateKey, Rule: "memory-request-missing", Severity: severityError, Message: "Memory Requests must be defined", Pods: 1},
+		{workloadContainer: webKey, Rule: "cpu-request-missing", Severity: severityError, Message: "CPU Requests must be defined", Pods: 2},
+		{workloadContainer: webKey, Rule: "memory-request-missing", Severity: severityError, Message: "Memory Requests must be defined", Pods: 2},
+		{workloadContainer: debugKey, Rule: "cpu-limit-missing", Severity: severityWarning, Message: "CPU Limits must be defined", Pods: 1},
+		{workloadContainer: debugKey, Rule: "memory-limit-missing", Severity: severityWarning, Message: "Memory Limits must be defined", Pods: 1},
+	}
+
+	report := lintPods(pods, owners, 4, severityError)
+	if !reflect.DeepEqual(report.Checked, wantChecked) {
+		t.Errorf("checked = %+v, want %+v", report.Checked, wantChecked)
+	}
+	if !reflect.DeepEqual(report.Findings, wantFindings) {
+		t.Errorf("findings = %+v, want %+v", report.Findings, wantFindings)
+	}
+
+	tests := []struct {
+		name   string
+		pods   []v1.Pod
+		failOn string
+		failed bool
+	}{
+		{name: "errors fail on error", pods: pods, failOn: severityError, failed: true},
+		{name: "warnings pass on error", pods: []v1.Pod{bare}, failOn: severityError, failed: false},
+		{name: "warnings fail on warning", pods: []v1.Pod{bare}, failOn: severityWarning, failed: true},
+		{name: "no findings pass on warning", pods: []v1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "tools", Name: "ok"}, Spec: v1.PodSpec{Containers: []v1.Container{defined}}}}, failOn: severityWarning, failed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if failed := lintPods(tt.pods, owners, 4, tt.failOn).Failed; failed != tt.failed {
+				t.Errorf("failed = %t, want %t", failed, tt.failed)
+			}
+		})
+	}
+}
+
+func TestWriteLintJUnit(t *testing.T) {
+	web := workloadContainer{Namespace: "shop", Kind: "Deployment", Workload: "web", Container: "app"}
+	debug := workloadContainer{Namespace: "tools", Kind: "Pod", Workload: "debug", Container: "app"}
+	report := lintReport{
+		Checked: []workloadContainer{web, debug},
+		Findings: []lintFinding{
+			{workloadContainer: web, Rule: "cpu-request-missing", Severity: severityError, Message: "CPU Requests must be defined", Pods: 2},
+			{workloadContainer: web, Rule: "memory-request-missing", Severity: severityError, Message: "Memory Requests must be defined", Pods: 2},
+			{workloadContainer: debug, Rule: "cpu-limit-missing", Severity: severityWarning, Message: "CPU Limits must be defined", Pods: 1},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		failOn    string
+		failures  int
+		debugFail int
+		debugOut  string
+	}{
+		{name: "warnings go to the output on error", failOn: severityError, failures: 1, debugOut: "warning: cpu-limit-missing: CPU Limits must be defined (1 pods)\n"},
+		{name: "warnings fail on warning", failOn: severityWarning, failures: 2, debugFail: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeLintJUnit(&out, report, tt.failOn); err != nil {
+				t.Fatalf("writing JUnit: %v", err)
+			}
+			if !strings.HasPrefix(out.String(), xml.Header) {
+				t.Errorf("output does not start with the XML header: %q", out.String())
+			}
+
+			suite := junitSuite{}
+			if err := xml.Unmarshal(out.Bytes(), &suite); err != nil {
+				t.Fatalf("reading JUnit back: %v", err)
+			}
+			if suite.Name != "kapct-lint" || suite.Tests != 2 || suite.Failures != tt.failures || len(suite.Cases) != 2 {
+				t.Fatalf("suite = %+v, want 2 tests and %d failures", suite, tt.failures)
+			}
+
+			webCase, debugCase := suite.Cases[0], suite.Cases[1]
+			if webCase.ClassName != "shop/Deployment/web" || webCase.Name != "app" {
+				t.Errorf("first case = %s %s, want shop/Deployment/web app", webCase.ClassName, webCase.Name)
+			}
+			wantWeb := []junitFailure{
+				{Type: "cpu-request-missing", Message: "CPU Requests must be defined (2 pods)"},
+				{Type: "memory-request-missing", Message: "Memory Requests must be defined (2 pods)"},
+			}
+			if !reflect.DeepEqual(webCase.Failures, wantWeb) {
+				t.Errorf("web failures = %+v, want %+v", webCase.Failures, wantWeb)
+			}
+			if len(debugCase.Failures) != tt.debugFail || debugCase.SystemOut != tt.debugOut {
+				t.Errorf("debug case = %+v, want %d failures and output %q", debugCase, tt.debugFail, tt.debugOut)
+			}
+		})
+	}
+}
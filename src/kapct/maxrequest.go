@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxRequest answers the reverse question of the capacity check, it searches for the
+// largest CPU and memory requests each of the replicas can have and still be scheduled.
+func maxRequest(args []string) {
+	var replicaAsk int
+	var cpuAsk string
+	var memoryAsk string
+	var ratio string
+
+	f := flag.NewFlagSet("max-request", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.IntVar(&replicaAsk, "replicas", 1, "number of replicas, you may want to deploy.")
+	f.StringVar(&cpuAsk, "cpureq", "100m", "amount of CPU held for every replica while searching for the largest memory request.")
+	f.StringVar(&memoryAsk, "memreq", "1G", "amount of memory held for every replica while searching for the largest CPU request.")
+	f.StringVar(&ratio, "ratio", "", "(optional) fixed CPU:memory ratio of the requests, e.g. 1:4G for 4G of memory per core.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct max-request [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if replicaAsk < 1 {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	w := newTabWriter()
+	workers := getWorkerNodes(newClientSet(*kubeconfig))
+
+	maxCPU, maxMemory := int64(0), int64(0)
+	for _, n := range workers {
+		if n.node.Spec.Unschedulable {
+			continue
+		}
+		remainingCPUReq, remainingMemoryReq, _ := n.remaining()
+		if remainingCPUReq > maxCPU {
+			maxCPU = remainingCPUReq
+		}
+		if remainingMemoryReq > maxMemory {
+			maxMemory = remainingMemoryReq
+		}
+	}
+
+	heldCPU, heldMemory := cpuToInt64(cpuAsk), ToBytes(memoryAsk)
+	largestCPU := searchLargest(maxCPU, func(cpu int64) bool { return replicasFit(workers, cpu, heldMemory, replicaAsk) })
+	largestMemory := searchLargest(maxMemory, func(memory int64) bool { return replicasFit(workers, heldCPU, memory, replicaAsk) })
+
+	Columns(w, "\n")
+	Rows(w, "%s\t%d\t%s\t%d\n", "Replica Requested via STDIN: ", replicaAsk, "Number of worker nodes: ", len(workers))
+	Rows(w, "%s\t%dm\t%s\t%s\n", "Largest CPU Request: ", largestCPU, "With Memory Request: ", memoryAsk)
+	Rows(w, "%s\t%s\t%s\t%s\n", "Largest Memory Request: ", FromBytes(largestMemory), "With CPU Request: ", cpuAsk)
+
+	if ratio != "" {
+		parts := strings.SplitN(ratio, ":", 2)
+		if len(parts) != 2 || cpuToInt64(parts[0]) <= 0 || ToBytes(parts[1]) <= 0 {
+			fmt.Println("There is a problem parsing ratio, use CPU:memory e.g. 1:4G!!")
+			os.Exit(2)
+		}
+
+		// memory follows the CPU at the ratio given, so searching the CPU alone is enough.
+		ratioCPU, ratioMemory := cpuToInt64(parts[0]), ToBytes(parts[1])
+		largestAtRatio := searchLargest(maxCPU, func(cpu int64) bool {
+			return replicasFit(workers, cpu, cpu*ratioMemory/ratioCPU, replicaAsk)
+		})
+		Rows(w, "%s\t%dm\t%s\t%s\n", "Largest CPU Request At Ratio: ", largestAtRatio, "Largest Memory Request At Ratio: ", FromBytes(largestAtRatio*ratioMemory/ratioCPU))
+	}
+
+	if largestCPU == 0 || largestMemory == 0 {
+		Rows(w, "%s\t%s\t\n", "Is Scheduleable?: ", "False")
+	} else {
+		Rows(w, "%s\t%s\t\n", "Is Scheduleable?: ", "True")
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// replicasFit tells if the replicas fit with the requests given, the same way the capacity check
+// counts them. Cordoned nodes take no new pods.
+func replicasFit(workers []*clusterNode, cpu int64, memory int64, replicas int) bool {
+	total := int64(0)
+	for _, n := range workers {
+		if n.node.Spec.Unschedulable {
+			continue
+		}
+		remainingCPUReq, remainingMemoryReq, freePods := n.remaining()
+		total += nodeFit(remainingCPUReq, remainingMemoryReq, cpu, memory, freePods)
+	}
+	return total >= int64(replicas)
+}
+
+// searchLargest returns the largest request, up to the limit, for which fits holds true, zero if
+// there is none. Larger requests never fit more replicas, so a binary search does it.
+func searchLargest(limit int64, fits func(int64) bool) int64 {
+	low, high := int64(0), limit
+	for low < high {
+		mid := low + (high-low+1)/2
+		if fits(mid) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// workerNode returns a clusterNode with the allocatable given and 110 pod slots, running the pods.
+func workerNode(name string, cpu string, memory string, pods ...v1.Pod) *clusterNode {
+	node := testNode(name, cpu, memory)
+	node.Status.Allocatable[v1.ResourcePods] = resource.MustParse("110")
+	return newClusterNode(node, pods)
+}
+
+func TestSearchLargest(t *testing.T) {
+	free := workerNode("worker-1", "4", "4Gi")
+	cordoned := workerNode("worker-2", "16", "64Gi")
+	cordoned.node.Spec.Unschedulable = true
+	busy := workerNode("worker-3", "4", "4Gi", testPod("shop", "web-1", "web", resources("1500m", "1Gi")))
+
+	tests := []struct {
+		name     string
+		workers  []*clusterNode
+		replicas int
+		limit    int64
+		fits     func(workers []*clusterNode, replicas int) func(int64) bool
+		largest  int64
+	}{
+		{
+			name:     "cpu filling the node exactly",
+			workers:  []*clusterNode{free},
+			replicas: 1,
+			limit:    4000,
+			fits:     cpuFits(GIGABYTE),
+			largest:  4000,
+		},
+		{
+			name:     "cpu filling the node with as many replicas as memory",
+			workers:  []*clusterNode{free},
+			replicas: 4,
+			limit:    4000,
+			fits:     cpuFits(GIGABYTE),
+			largest:  1000,
+		},
+		{
+			name:     "memory filling the node exactly",
+			workers:  []*clusterNode{free},
+			replicas: 2,
+			limit:    4 * GIGABYTE,
+			fits:     memoryFits(1000),
+			largest:  2 * GIGABYTE,
+		},
+		{
+			name:     "cordoned nodes take none",
+			workers:  []*clusterNode{free, cordoned},
+			replicas: 1,
+			limit:    16000,
+			fits:     cpuFits(GIGABYTE),
+			largest:  4000,
+		},
+		{
+			name:     "replicas spread over the nodes",
+			workers:  []*clusterNode{free, busy},
+			replicas: 2,
+			limit:    4000,
+			fits:     cpuFits(GIGABYTE),
+			largest:  2500,
+		},
+		{
+			name:     "more replicas than fit",
+			workers:  []*clusterNode{free},
+			replicas: 5,
+			limit:    4000,
+			fits:     cpuFits(GIGABYTE),
+			largest:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if largest := searchLargest(tt.limit, tt.fits(tt.workers, tt.replicas)); largest != tt.largest {
+				t.Errorf("largest = %d, want %d", largest, tt.largest)
+			}
+		})
+	}
+}
+
+// cpuFits searches the CPU request with the memory request held.
+func cpuFits(memory int64) func([]*clusterNode, int) func(int64) bool {
+	return func(workers []*clusterNode, replicas int) func(int64) bool {
+		return func(cpu int64) bool { return replicasFit(workers, cpu, memory, replicas) }
+	}
+}
+
+// memoryFits searches the memory request with the CPU request held.
+func memoryFits(cpu int64) func([]*clusterNode, int) func(int64) bool {
+	return func(workers []*clusterNode, replicas int) func(int64) bool {
+		return func(memory int64) bool { return replicasFit(workers, cpu, memory, replicas) }
+	}
+}
+
+func TestReplicasFitIsMonotonic(t *testing.T) {
+	workers := []*clusterNode{workerNode("worker-1", "4", "4Gi")}
+	// every CPU request up to the one filling the node fits, none past it does.
+	for cpu := int64(1); cpu <= 4100; cpu++ {
+		if fits := replicasFit(workers, cpu, GIGABYTE, 1); fits != (cpu <= 4000) {
+			t.Fatalf("replicasFit(%dm, 1Gi) = %t, want %t", cpu, fits, cpu <= 4000)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cmd "k8s.io/client-go/tools/clientcmd"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// resourceUsage holds what a node, a namespace or a workload requests next to what it uses.
+type resourceUsage struct {
+	name              string
+	pods              int
+	cpuReq            int64
+	cpuUsage          int64
+	memoryReq         int64
+	memoryUsage       int64
+	cpuAllocatable    int64
+	memoryAllocatable int64
+}
+
+// usage compares the requests with the actual usage from the metrics.k8s.io API, per worker node
+// and per namespace. Workloads requesting more than over-ratio times what they use are
+// over-provisioned, the ones using more than they request are under-requested.
+func usage(args []string) {
+	var overRatio float64
+
+	f := flag.NewFlagSet("usage", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.Float64Var(&overRatio, "over-ratio", 2, "ratio of requests to usage above which a workload is over-provisioned.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct usage [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+	workers := getWorkerNodes(c)
+	m := newMetricsClientSet(*kubeconfig)
+
+	// the workloads line up with the ones of lint and suggest, a Deployment is a single row.
+	nodes, namespaces, workloads := usagePerGroup(workers, getWorkloadOwners(c, ""), getNodeMetrics(m), getPodMetrics(m))
+	printUsage(w, "Usage Per Node", "Nodes", nodes, true)
+	printUsage(w, "Usage Per Namespace", "Namespace", namespaces, false)
+
+	overProvisioned, underRequested := classifyUsage(workloads, overRatio)
+	Rows(w, "%s\t%d\t%s\t%4.2f\n", "Over-Provisioned Workloads: ", len(overProvisioned), "Over Ratio: ", overRatio)
+	Rows(w, "%s\t%s\t", "Over-Provisioned Workloads List: ", VPrint(overProvisioned))
+	Rows(w, "%s\t%d\t\n", "Under-Requested Workloads: ", len(underRequested))
+	Rows(w, "%s\t%s\t", "Under-Requested Workloads List: ", VPrint(underRequested))
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// classifyUsage returns the workloads requesting more than overRatio times what they use, and
+// the ones using more than they request, along with their requests and usage.
+func classifyUsage(workloads []resourceUsage, overRatio float64) ([]string, []string) {
+	overProvisioned, underRequested := make([]string, 0, 3), make([]string, 0, 3)
+	for _, workload := range workloads {
+		if (workload.cpuUsage > 0 && float64(workload.cpuReq) > overRatio*float64(workload.cpuUsage)) || (workload.memoryUsage > 0 && float64(workload.memoryReq) > overRatio*float64(workload.memoryUsage)) {
+			overProvisioned = append(overProvisioned, fmt.Sprintf("%s (cpu %dm/%dm, memory %s/%s)", workload.name, workload.cpuReq, workload.cpuUsage, FromBytes(workload.memoryReq), FromBytes(workload.memoryUsage)))
+		}
+		if workload.cpuUsage > workload.cpuReq || workload.memoryUsage > workload.memoryReq {
+			underRequested = append(underRequested, fmt.Sprintf("%s (cpu %dm/%dm, memory %s/%s)", workload.name, workload.cpuReq, workload.cpuUsage, FromBytes(workload.memoryReq), FromBytes(workload.memoryUsage)))
+		}
+	}
+	return overProvisioned, underRequested
+}
+
+// newMetricsClientSet creates a client of the metrics.k8s.io API from the kubeconfig file.
+func newMetricsClientSet(kubeconfig string) metrics.Interface {
+	loadConfig, err := cmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		fmt.Println("There is a problem loading kubeconfig file!!")
+		panic(err.Error())
+	}
+
+	clientSet, err := metrics.NewForConfig(loadConfig)
+	if err != nil {
+		fmt.Println("There is a problem creating a metrics client!!")
+		panic(err.Error())
+	}
+	return clientSet
+}
+
+// getNodeMetrics returns the CPU and memory the nodes use, by node name.
+func getNodeMetrics(m metrics.Interface) map[string]v1.ResourceList {
+	nodeMetrics, err := m.MetricsV1beta1().NodeMetricses().List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting node metrics, is metrics-server running?!!")
+		panic(err.Error())
+	}
+
+	usage := make(map[string]v1.ResourceList, len(nodeMetrics.Items))
+	for _, metric := range nodeMetrics.Items {
+		usage[metric.Name] = metric.Usage
+	}
+	return usage
+}
+
+// listPodMetrics lists the metrics of the pods of the namespace, of all the namespaces if empty.
+func listPodMetrics(m metrics.Interface, namespace string) []metricsv1beta1.PodMetrics {
+	podMetrics, err := m.MetricsV1beta1().PodMetricses(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting pod metrics, is metrics-server running?!!")
+		panic(err.Error())
+	}
+	return podMetrics.Items
+}
+
+// getPodMetrics returns the CPU and memory the pods use, the sum of their containers, by pod key.
+func getPodMetrics(m metrics.Interface) map[string]v1.ResourceList {
+	podMetrics := listPodMetrics(m, "")
+	usage := make(map[string]v1.ResourceList, len(podMetrics))
+	for _, metric := range podMetrics {
+		total := make(v1.ResourceList)
+		for _, container := range metric.Containers {
+			for name, quantity := range container.Usage {
+				sum := total[name]
+				sum.Add(quantity)
+				total[name] = sum
+			}
+		}
+		usage[metric.Namespace+"/"+metric.Name] = total
+	}
+	return usage
+}
+
+// getContainerMetrics returns the CPU and memory the containers of the namespace use, by
+// namespace/pod/container.
+func getContainerMetrics(m metrics.Interface, namespace string) map[string]v1.ResourceList {
+	usage := make(map[string]v1.ResourceList)
+	for _, metric := range listPodMetrics(m, namespace) {
+		for _, container := range metric.Containers {
+			usage[metric.Namespace+"/"+metric.Name+"/"+container.Name] = container.Usage
+		}
+	}
+	return usage
+}
+
+// workloadName returns the namespace and the workload owning the pod, a Deployment rather than
+// the ReplicaSets of its revisions, the pod itself if it has no controller.
+func workloadName(p *v1.Pod, owners map[string]metav1.OwnerReference) string {
+	if metav1.GetControllerOf(p) == nil {
+		return podKey(p)
+	}
+	kind, name := owningWorkload(p, owners)
+	return fmt.Sprintf("%s/%s/%s", p.Namespace, kind, name)
+}
+
+// usagePerGroup sums up the requests and the usage of the pods on the worker nodes per node,
+// per namespace and per workload, the workloads going by the owners given. Nodes use what the
+// node metrics tell, the rest what their pods use.
+func usagePerGroup(workers []*clusterNode, owners map[string]metav1.OwnerReference, nodeUsage map[string]v1.ResourceList, podUsage map[string]v1.ResourceList) ([]resourceUsage, []resourceUsage, []resourceUsage) {
+	nodes := make([]resourceUsage, 0, len(workers))
+	namespaces, workloads := make(map[string]*resourceUsage), make(map[string]*resourceUsage)
+
+	for _, n := range workers {
+		used := nodeUsage[n.node.Name]
+		nodes = append(nodes, resourceUsage{
+			name:              n.name,
+			pods:              len(n.pods),
+			cpuReq:            n.cpuReq,
+			cpuUsage:          used.Cpu().MilliValue(),
+			memoryReq:         n.memoryReq,
+			memoryUsage:       used.Memory().Value(),
+			cpuAllocatable:    n.cpuAllocatable,
+			memoryAllocatable: n.memoryAllocatable,
+		})
+
+		for i := range n.pods {
+			p := &n.pods[i]
+			cpuReq, memoryReq := podRequests(p)
+			used := podUsage[podKey(p)]
+			add := func(groups map[string]*resourceUsage, name string) {
+				group, ok := groups[name]
+				if !ok {
+					group = &resourceUsage{name: name}
+					groups[name] = group
+				}
+				group.pods++
+				group.cpuReq, group.memoryReq = group.cpuReq+cpuReq, group.memoryReq+memoryReq
+				group.cpuUsage, group.memoryUsage = group.cpuUsage+used.Cpu().MilliValue(), group.memoryUsage+used.Memory().Value()
+			}
+			add(namespaces, p.Namespace)
+			add(workloads, workloadName(p, owners))
+		}
+	}
+
+	return nodes, sortedUsage(namespaces), sortedUsage(workloads)
+}
+
+// sortedUsage returns the groups sorted by name.
+func sortedUsage(groups map[string]*resourceUsage) []resourceUsage {
+	sorted := make([]resourceUsage, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	return sorted
+}
+
+// printUsage prints the requests next to the usage of the groups, along with the part of the
+// allocatable they use when they are nodes.
+func printUsage(p *tabwriter.Writer, title string, column string, groups []resourceUsage, allocatable bool) {
+	Columns(p, "\n")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", title)
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	if allocatable {
+		Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", column, "Pods", "CpuReq", "CpuUsage", "MemReq", "MemUsage", "CpuUsage%", "MemUsage%")
+	} else {
+		Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", column, "Pods", "CpuReq", "CpuUsage", "MemReq", "MemUsage")
+	}
+	for _, group := range groups {
+		Rows(p, "%2s\t%4d\t%5dm\t%7dm\t%5dM\t%7dM\t", group.name, group.pods, group.cpuReq, group.cpuUsage, group.memoryReq/MEGABYTE, group.memoryUsage/MEGABYTE)
+		if allocatable {
+			Rows(p, "%8.2f%%\t%8.2f%%\t", percentOf(group.cpuUsage, group.cpuAllocatable), percentOf(group.memoryUsage, group.memoryAllocatable))
+		}
+		Columns(p, "\n")
+	}
+	Columns(p, "\n")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// the fake clientset lists the metrics by these resources, the tracker has to hold them there.
+var (
+	nodeMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+	podMetricsResource  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+func resources(cpu string, memory string) v1.ResourceList {
+	return v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)}
+}
+
+func testNode(name string, cpu string, memory string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     v1.NodeStatus{Allocatable: resources(cpu, memory)},
+	}
+}
+
+// testPod returns a pod of a ReplicaSet, with a container per requests given.
+func testPod(namespace string, name string, replicaSet string, requests ...v1.ResourceList) v1.Pod {
+	controller := true
+	p := v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       namespace,
+		Name:            name,
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet, Controller: &controller}},
+	}}
+	for i, request := range requests {
+		p.Spec.Containers = append(p.Spec.Containers, v1.Container{Name: string(rune('a' + i)), Resources: v1.ResourceRequirements{Requests: request}})
+	}
+	return p
+}
+
+func fakeMetrics(t *testing.T, objects ...runtime.Object) *fake.Clientset {
+	m := fake.NewSimpleClientset()
+	for _, obj := range objects {
+		var err error
+		switch metric := obj.(type) {
+		case *metricsv1beta1.NodeMetrics:
+			err = m.Tracker().Create(nodeMetricsResource, metric, "")
+		case *metricsv1beta1.PodMetrics:
+			err = m.Tracker().Create(podMetricsResource, metric, metric.Namespace)
+		}
+		if err != nil {
+			t.Fatalf("adding %T to the fake clientset: %v", obj, err)
+		}
+	}
+	return m
+}
+
+func TestGetNodeMetrics(t *testing.T) {
+	m := fakeMetrics(t,
+		&metricsv1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Usage: resources("1500m", "2Gi")},
+		&metricsv1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Usage: resources("250m", "512Mi")},
+	)
+
+	usage := getNodeMetrics(m)
+	if len(usage) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(usage))
+	}
+	worker1, worker2 := usage["worker-1"], usage["worker-2"]
+	if cpu := worker1.Cpu().MilliValue(); cpu != 1500 {
+		t.Errorf("worker-1 cpu = %dm, want 1500m", cpu)
+	}
+	if memory := worker2.Memory().Value(); memory != 512*MEGABYTE {
+		t.Errorf("worker-2 memory = %d, want %d", memory, 512*MEGABYTE)
+	}
+}
+
+func TestGetPodMetrics(t *testing.T) {
+	m := fakeMetrics(t, &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-1"},
+		Containers: []metricsv1beta1.ContainerMetrics{
+			{Name: "app", Usage: resources("300m", "256Mi")},
+			{Name: "sidecar", Usage: resources("50m", "64Mi")},
+		},
+	})
+
+	usage := getPodMetrics(m)
+	used, ok := usage["shop/web-1"]
+	if !ok {
+		t.Fatalf("no usage for shop/web-1 in %v", usage)
+	}
+	if cpu := used.Cpu().MilliValue(); cpu != 350 {
+		t.Errorf("cpu = %dm, want the containers summed up to 350m", cpu)
+	}
+	if memory := used.Memory().Value(); memory != 320*MEGABYTE {
+		t.Errorf("memory = %d, want the containers summed up to %d", memory, 320*MEGABYTE)
+	}
+}
+
+func TestUsagePerGroup(t *testing.T) {
+	m := fakeMetrics(t,
+		&metricsv1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Usage: resources("1", "3Gi")},
+		&metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-1"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "a", Usage: resources("100m", "128Mi")}},
+		},
+		&metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-2"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "a", Usage: resources("100m", "128Mi")}},
+		},
+		&metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "worker-1"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "a", Usage: resources("900m", "1Gi")}},
+		},
+		&metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "report-1"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "a", Usage: resources("200m", "256Mi")}, {Name: "b", Usage: resources("100m", "256Mi")}},
+		},
+	)
+
+	workers := []*clusterNode{newClusterNode(testNode("worker-1", "4", "8Gi"), []v1.Pod{
+		testPod("shop", "web-1", "web-6d4f", resources("1", "1Gi")),
+		testPod("shop", "web-2", "web-5b8c", resources("1", "1Gi")),
+		testPod("shop", "worker-1", "worker", resources("500m", "512Mi")),
+		testPod("batch", "report-1", "report", resources("200m", "256Mi"), resources("100m", "256Mi")),
+	})}
+
+	// web-6d4f and web-5b8c are two revisions of the web Deployment, they make a single workload.
+	owners := map[string]metav1.OwnerReference{
+		"shop/ReplicaSet/web-6d4f": {Kind: "Deployment", Name: "web"},
+		"shop/ReplicaSet/web-5b8c": {Kind: "Deployment", Name: "web"},
+	}
+	nodes, namespaces, workloads := usagePerGroup(workers, owners, getNodeMetrics(m), getPodMetrics(m))
+
+	wantNodes := []resourceUsage{{name: "worker-1", pods: 4, cpuReq: 2800, cpuUsage: 1000, memoryReq: 3072 * MEGABYTE, memoryUsage: 3072 * MEGABYTE, cpuAllocatable: 4000, memoryAllocatable: 8192 * MEGABYTE}}
+	if !reflect.DeepEqual(nodes, wantNodes) {
+		t.Errorf("nodes = %+v, want %+v", nodes, wantNodes)
+	}
+
+	wantNamespaces := []resourceUsage{
+		{name: "batch", pods: 1, cpuReq: 300, cpuUsage: 300, memoryReq: 512 * MEGABYTE, memoryUsage: 512 * MEGABYTE},
+		{name: "shop", pods: 3, cpuReq: 2500, cpuUsage: 1100, memoryReq: 2560 * MEGABYTE, memoryUsage: 1280 * MEGABYTE},
+	}
+	if !reflect.DeepEqual(namespaces, wantNamespaces) {
+		t.Errorf("namespaces = %+v, want %+v", namespaces, wantNamespaces)
+	}
+
+	wantWorkloads := []resourceUsage{
+		{name: "batch/ReplicaSet/report", pods: 1, cpuReq: 300, cpuUsage: 300, memoryReq: 512 * MEGABYTE, memoryUsage: 512 * MEGABYTE},
+		{name: "shop/Deployment/web", pods: 2, cpuReq: 2000, cpuUsage: 200, memoryReq: 2048 * MEGABYTE, memoryUsage: 256 * MEGABYTE},
+		{name: "shop/ReplicaSet/worker", pods: 1, cpuReq: 500, cpuUsage: 900, memoryReq: 512 * MEGABYTE, memoryUsage: 1024 * MEGABYTE},
+	}
+	if !reflect.DeepEqual(workloads, wantWorkloads) {
+		t.Errorf("workloads = %+v, want %+v", workloads, wantWorkloads)
+	}
+}
+
+func TestClassifyUsage(t *testing.T) {
+	workloads := []resourceUsage{
+		{name: "shop/ReplicaSet/web", cpuReq: 2000, cpuUsage: 200, memoryReq: 2048 * MEGABYTE, memoryUsage: 256 * MEGABYTE},
+		{name: "shop/ReplicaSet/worker", cpuReq: 500, cpuUsage: 900, memoryReq: 512 * MEGABYTE, memoryUsage: 1024 * MEGABYTE},
+		{name: "batch/ReplicaSet/report", cpuReq: 300, cpuUsage: 300, memoryReq: 512 * MEGABYTE, memoryUsage: 512 * MEGABYTE},
+		{name: "shop/ReplicaSet/idle", cpuReq: 100, memoryReq: 128 * MEGABYTE},
+	}
+
+	tests := []struct {
+		name      string
+		overRatio float64
+		over      []string
+		under     []string
+	}{
+		{
+			name:      "default ratio",
+			overRatio: 2,
+			over:      []string{"shop/ReplicaSet/web (cpu 2000m/200m, memory 2G/256M)"},
+			under:     []string{"shop/ReplicaSet/worker (cpu 500m/900m, memory 512M/1G)"},
+		},
+		{
+			name:      "ratio above the one of web",
+			overRatio: 10,
+			over:      []string{},
+			under:     []string{"shop/ReplicaSet/worker (cpu 500m/900m, memory 512M/1G)"},
+		},
+		{
+			name:      "ratio of one flags the workloads requesting more than they use, not the ones without usage",
+			overRatio: 1,
+			over:      []string{"shop/ReplicaSet/web (cpu 2000m/200m, memory 2G/256M)"},
+			under:     []string{"shop/ReplicaSet/worker (cpu 500m/900m, memory 512M/1G)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			over, under := classifyUsage(workloads, tt.overRatio)
+			if !reflect.DeepEqual(over, tt.over) {
+				t.Errorf("over-provisioned = %v, want %v", over, tt.over)
+			}
+			if !reflect.DeepEqual(under, tt.under) {
+				t.Errorf("under-requested = %v, want %v", under, tt.under)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// the namespaces exempt from the resource hygiene warnings when told nothing else.
+const (
+	defaultExemptNamespaces = "kube-system,ingress-nginx,kubernetes-dashboard"
+	defaultExemptSelector   = "kapct.io/exempt=true"
+)
+
+// namespaceFilter tells the namespaces exempt from the resource hygiene warnings, the ones left
+// out of the include list when there is one, in the exclude list or selected by their labels.
+// Optionally the pods of the exempt namespaces are left out of the capacity math as well.
+type namespaceFilter struct {
+	include             []string
+	exclude             []string
+	selector            labels.Selector
+	excludeFromCapacity bool
+}
+
+// newNamespaceFilter creates a namespaceFilter out of the comma separated namespace lists and the
+// label selector, it returns an error if the selector does not parse.
+func newNamespaceFilter(include string, exclude string, selector string, excludeFromCapacity bool) (namespaceFilter, error) {
+	filter := namespaceFilter{
+		include:             splitList(include),
+		exclude:             splitList(exclude),
+		selector:            labels.Nothing(),
+		excludeFromCapacity: excludeFromCapacity,
+	}
+	if selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return filter, err
+		}
+		filter.selector = parsed
+	}
+	return filter, nil
+}
+
+// splitList splits the comma separated list, leaving out the empty items.
+func splitList(list string) []string {
+	items := make([]string, 0, 3)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// exempt tells if the namespace is exempt from the resource hygiene warnings.
+func (f namespaceFilter) exempt(ns *v1.Namespace) bool {
+	if len(f.include) > 0 && !containsString(f.include, ns.Name) {
+		return true
+	}
+	if containsString(f.exclude, ns.Name) {
+		return true
+	}
+	return f.selector != nil && f.selector.Matches(labels.Set(ns.Labels))
+}
+
+// exemptNamespaces returns the names of the exempt namespaces.
+func (f namespaceFilter) exemptNamespaces(namespaces []v1.Namespace) map[string]bool {
+	exempt := make(map[string]bool)
+	for i := range namespaces {
+		if f.exempt(&namespaces[i]) {
+			exempt[namespaces[i].Name] = true
+		}
+	}
+	return exempt
+}
+
+// containsString tells if the item is in the list.
+func containsString(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceSwitches holds the switches the namespaceFilter is made of.
+type namespaceSwitches struct {
+	include       string
+	exclude       string
+	selector      string
+	excludeExempt bool
+}
+
+// namespaceFlags declares the switches of the namespaceFilter on the flag set, -exclude-exempt
+// only for the commands doing the capacity math.
+func namespaceFlags(f *flag.FlagSet, capacity bool) *namespaceSwitches {
+	s := &namespaceSwitches{}
+	f.StringVar(&s.include, "include-namespaces", "", "(optional) comma separated namespaces warned about undefined requests and limits, all but the exempt ones otherwise.")
+	f.StringVar(&s.exclude, "exclude-namespaces", defaultExemptNamespaces, "comma separated namespaces exempt from the warnings about undefined requests and limits.")
+	f.StringVar(&s.selector, "exempt-selector", defaultExemptSelector, "label selector of the namespaces exempt from the warnings about undefined requests and limits.")
+	if capacity {
+		f.BoolVar(&s.excludeExempt, "exclude-exempt", false, "leave the pods of the exempt namespaces out of the capacity math as well.")
+	}
+	return s
+}
+
+// filter creates the namespaceFilter out of the parsed switches.
+func (s *namespaceSwitches) filter() (namespaceFilter, error) {
+	return newNamespaceFilter(s.include, s.exclude, s.selector, s.excludeExempt)
+}
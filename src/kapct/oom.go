@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// memoryRisk holds how far the memory limits of the pods on a node go past its allocatable, the
+// pods without a memory limit counted at their estimate.
+type memoryRisk struct {
+	node        string
+	allocatable int64
+	requests    int64
+	limits      int64
+	claimable   int64
+	unbounded   int
+	qos         map[v1.PodQOSClass]int
+	evictions   []string
+}
+
+// evictionCandidate is a pod ranked the way the kubelet picks pods to evict under memory pressure.
+type evictionCandidate struct {
+	pod      *v1.Pod
+	request  int64
+	usage    int64
+	priority int32
+}
+
+// oomRisk reports the memory overcommit of the worker nodes: the memory limits against the
+// allocatable, the QoS classes of the pods, the memory they may claim above their requests
+// and the pods the kubelet would evict first once the node runs short of memory.
+func oomRisk(args []string) {
+	var withMetrics bool
+	var top int
+	var unboundedEstimate string
+
+	f := flag.NewFlagSet("oom-risk", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.BoolVar(&withMetrics, "metrics", false, "(optional) rank the pods for eviction by their usage from the metrics.k8s.io API, by their limits otherwise.")
+	f.IntVar(&top, "top", 5, "number of pods listed per node in eviction order.")
+	f.StringVar(&unboundedEstimate, "unbounded-estimate", "1G", "memory a container without a memory limit is taken to use at most, its request if more, or its usage with -metrics if more.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct oom-risk [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	w := newTabWriter()
+	workers := getWorkerNodes(newClientSet(*kubeconfig))
+
+	podUsage := make(map[string]v1.ResourceList)
+	if withMetrics {
+		podUsage = getPodMetrics(newMetricsClientSet(*kubeconfig))
+	}
+
+	risks := make([]memoryRisk, 0, len(workers))
+	for _, n := range workers {
+		risks = append(risks, nodeMemoryRisk(n, podUsage, ToBytes(unboundedEstimate), top))
+	}
+	printMemoryRisk(w, risks)
+}
+
+// memoryCeiling returns the most memory the pod is taken to use: the memory limits of its
+// containers, the estimate or the request, whichever is more, for the ones without a limit.
+// It tells as well if a container of the pod has no memory limit.
+func memoryCeiling(p *v1.Pod, estimate int64) (int64, bool) {
+	containerCeiling := func(container v1.Container) (int64, bool) {
+		if limit, ok := container.Resources.Limits[v1.ResourceMemory]; ok && !limit.IsZero() {
+			return limit.Value(), false
+		}
+		if request := container.Resources.Requests.Memory().Value(); request > estimate {
+			return request, true
+		}
+		return estimate, true
+	}
+
+	// the init containers run one after another before the containers, the largest of them counts.
+	ceiling, unbounded := int64(0), false
+	for _, container := range p.Spec.Containers {
+		most, noLimit := containerCeiling(container)
+		ceiling, unbounded = ceiling+most, unbounded || noLimit
+	}
+	for _, container := range p.Spec.InitContainers {
+		if most, _ := containerCeiling(container); most > ceiling {
+			ceiling = most
+		}
+	}
+	return ceiling, unbounded
+}
+
+// nodeMemoryRisk calculates the memory risk of the node, it is at risk when the requests along
+// with the memory the pods may claim above them go past the allocatable. A pod without a memory
+// limit may claim up to the estimate, or up to what it uses when that is more. The pods are
+// ranked for eviction by their usage when there is one, by the most they may use otherwise.
+func nodeMemoryRisk(n *clusterNode, podUsage map[string]v1.ResourceList, estimate int64, top int) memoryRisk {
+	risk := memoryRisk{node: n.name, allocatable: n.memoryAllocatable, qos: make(map[v1.PodQOSClass]int)}
+
+	candidates := make([]evictionCandidate, 0, len(n.pods))
+	for i := range n.pods {
+		p := &n.pods[i]
+		_, request := podRequests(p)
+		ceiling, unbounded := memoryCeiling(p, estimate)
+		used, measured := podUsage[podKey(p)]
+		if unbounded {
+			risk.unbounded++
+			if measured && used.Memory().Value() > ceiling {
+				ceiling = used.Memory().Value()
+			}
+		}
+		qos := podQOS(p)
+
+		risk.qos[qos]++
+		risk.requests += request
+		risk.limits += ceiling
+		if ceiling > request {
+			risk.claimable += ceiling - request
+		}
+
+		if qos == v1.PodQOSGuaranteed {
+			continue
+		}
+		usage := ceiling
+		if measured {
+			usage = used.Memory().Value()
+		}
+		candidates = append(candidates, evictionCandidate{pod: p, request: request, usage: usage, priority: podPriority(p)})
+	}
+
+	// the pods using more than they request go first, then the lower priority, then the most above the requests.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		exceedsI, exceedsJ := candidates[i].usage > candidates[i].request, candidates[j].usage > candidates[j].request
+		if exceedsI != exceedsJ {
+			return exceedsI
+		}
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].usage-candidates[i].request > candidates[j].usage-candidates[j].request
+	})
+	for i := 0; i < len(candidates) && i < top; i++ {
+		risk.evictions = append(risk.evictions, fmt.Sprintf("%s: %s (%s, priority %d)", n.name, podKey(candidates[i].pod), podQOS(candidates[i].pod), candidates[i].priority))
+	}
+
+	return risk
+}
+
+// printMemoryRisk prints the memory overcommit per node and the pods first in line for eviction.
+func printMemoryRisk(p *tabwriter.Writer, risks []memoryRisk) {
+	Columns(p, "\n")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Memory Overcommit Per Node")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", "Nodes", "MemLimit%", "Guaranteed", "Burstable", "BestEffort", "Unbounded", "Claimable", "MemAlloc", "PressureRisk")
+
+	atRisk, unboundedNodes, evictions := make([]string, 0, 3), make([]string, 0, 3), make([]string, 0, 3)
+	for _, risk := range risks {
+		pressure := risk.requests+risk.claimable > risk.allocatable
+		if risk.unbounded > 0 {
+			unboundedNodes = append(unboundedNodes, fmt.Sprintf("%s (%d pods)", risk.node, risk.unbounded))
+		}
+		Rows(p, "%2s\t%8.2f%%\t%10d\t%9d\t%10d\t%9d\t%8dM\t%7dM\t%12t\t\n", risk.node, percentOf(risk.limits, risk.allocatable), risk.qos[v1.PodQOSGuaranteed], risk.qos[v1.PodQOSBurstable], risk.qos[v1.PodQOSBestEffort], risk.unbounded, risk.claimable/MEGABYTE, risk.allocatable/MEGABYTE, pressure)
+		if pressure {
+			atRisk = append(atRisk, risk.node)
+			evictions = append(evictions, risk.evictions...)
+		}
+	}
+	Columns(p, "\n")
+
+	Rows(p, "%s\t%d\t%s\t%d\n", "Number of worker nodes: ", len(risks), "Nodes At Memory Pressure Risk: ", len(atRisk))
+	Rows(p, "%s\t%s\t", "Nodes At Risk List: ", VPrint(atRisk))
+	Rows(p, "%s\t%d\t\n", "Nodes With Pods Without Memory Limit: ", len(unboundedNodes))
+	Rows(p, "%s\t%s\t", "Pods Without Memory Limit List: ", VPrint(unboundedNodes))
+	Rows(p, "%s\t%s\t", "Eviction Order List: ", VPrint(evictions))
+	Columns(p, "\n")
+	p.Flush()
+}
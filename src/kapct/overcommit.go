@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// poolLabels are the labels the managed node pools go by, looked into when no pool label is given.
+var poolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"agentpool",
+	"kops.k8s.io/instancegroup",
+}
+
+// overcommitLimit holds how far, in percent of the allocatable, the limits may go on a node.
+type overcommitLimit struct {
+	cpu    float64
+	memory float64
+}
+
+// overcommitThresholds holds the overcommit limits per node pool, the one for the empty pool
+// applies to the rest. It implements the flag.Value interface.
+type overcommitThresholds struct {
+	poolLabel string
+	limits    map[string]overcommitLimit
+}
+
+func (t *overcommitThresholds) String() string {
+	if t == nil {
+		return ""
+	}
+	pools := make([]string, 0, len(t.limits))
+	for pool := range t.limits {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+
+	parts := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		limit := fmt.Sprintf("cpu:%g,memory:%g", t.limits[pool].cpu, t.limits[pool].memory)
+		if pool != "" {
+			limit = pool + "=" + limit
+		}
+		parts = append(parts, limit)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set parses a [pool=]cpu:percent,memory:percent switch e.g. batch=cpu:200,memory:110, without
+// a pool it sets the limits of the nodes of the other pools. Left out resources stay at 100.
+func (t *overcommitThresholds) Set(value string) error {
+	pool, percents := "", value
+	if parts := strings.SplitN(value, "=", 2); len(parts) == 2 {
+		pool, percents = parts[0], parts[1]
+	}
+
+	limit := overcommitLimit{cpu: 100, memory: 100}
+	for _, pair := range strings.Split(percents, ",") {
+		fields := strings.SplitN(pair, ":", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("%q is not in resource:percent format", pair)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+		if err != nil || percent <= 0 {
+			return fmt.Errorf("%q is not a valid percent", fields[1])
+		}
+		switch fields[0] {
+		case "cpu":
+			limit.cpu = percent
+		case "memory":
+			limit.memory = percent
+		default:
+			return fmt.Errorf("%q is not cpu or memory", fields[0])
+		}
+	}
+
+	if t.limits == nil {
+		t.limits = make(map[string]overcommitLimit)
+	}
+	t.limits[pool] = limit
+	return nil
+}
+
+// overcommitFlags declares the -overcommit and -pool-label switches on the flag set.
+func overcommitFlags(f *flag.FlagSet) *overcommitThresholds {
+	thresholds := &overcommitThresholds{}
+	f.Var(thresholds, "overcommit", "(optional) limits as percent of the allocatable past which a node is overcommitted, in [pool=]cpu:percent,memory:percent format e.g. batch=cpu:200,memory:110, repeat the switch for more than one node pool. (default cpu:100,memory:100)")
+	f.StringVar(&thresholds.poolLabel, "pool-label", "", "(optional) node label telling the node pool of a node, the labels of the GKE, EKS, AKS and kops node pools are looked into otherwise.")
+	return thresholds
+}
+
+// nodePool returns the node pool of the node, empty if it is in none.
+func (t *overcommitThresholds) nodePool(node *v1.Node) string {
+	if t.poolLabel != "" {
+		return node.Labels[t.poolLabel]
+	}
+	for _, label := range poolLabels {
+		if pool, ok := node.Labels[label]; ok {
+			return pool
+		}
+	}
+	return ""
+}
+
+// forNode returns the overcommit limit of the node pool of the node, the limits may reach the
+// allocatable and no further unless told otherwise.
+func (t *overcommitThresholds) forNode(node *v1.Node) overcommitLimit {
+	if limit, ok := t.limits[t.nodePool(node)]; ok {
+		return limit
+	}
+	if limit, ok := t.limits[""]; ok {
+		return limit
+	}
+	return overcommitLimit{cpu: 100, memory: 100}
+}
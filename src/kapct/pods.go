@@ -0,0 +1,209 @@
+package main
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// podRequests returns the effective CPU(in milicores) and memory(in bytes) requests of the pod.
+func podRequests(p *v1.Pod) (int64, int64) {
+	return specRequests(&p.Spec)
+}
+
+// specRequests returns the effective CPU(in milicores) and memory(in bytes) requests of the pod spec.
+func specRequests(spec *v1.PodSpec) (int64, int64) {
+	requests := effectiveResources(spec, requestsOf)
+	return requests.Cpu().MilliValue(), requests.Memory().Value()
+}
+
+// effectiveResources returns the resources, requests or limits as picked, the pod spec needs on
+// a node. It is the sum over its containers or the largest of its init containers, whichever
+// is more, since the init containers run one after another before the containers start.
+func effectiveResources(spec *v1.PodSpec, pick func(v1.ResourceRequirements) v1.ResourceList) v1.ResourceList {
+	total := v1.ResourceList{}
+	for _, container := range spec.Containers {
+		for name, quantity := range pick(container.Resources) {
+			sum := total[name]
+			sum.Add(quantity)
+			total[name] = sum
+		}
+	}
+	for _, container := range spec.InitContainers {
+		for name, quantity := range pick(container.Resources) {
+			if sum, ok := total[name]; !ok || quantity.Cmp(sum) > 0 {
+				total[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return total
+}
+
+// requestsOf picks the requests of the container resources.
+func requestsOf(resources v1.ResourceRequirements) v1.ResourceList {
+	return resources.Requests
+}
+
+// limitsOf picks the limits of the container resources.
+func limitsOf(resources v1.ResourceRequirements) v1.ResourceList {
+	return resources.Limits
+}
+
+// nodeFit returns how many pods, each asking for cpuAsk and memoryAsk, fit into
+// the remaining resources and the free pod slots of a node, the least of the three.
+func nodeFit(remainingCPUReq int64, remainingMemoryReq int64, cpuAsk int64, memoryAsk int64, freePods int64) int64 {
+	// a resource the pod does not ask for does not limit it, as with the scheduler.
+	if freePods <= 0 || (cpuAsk > 0 && remainingCPUReq < cpuAsk) || (memoryAsk > 0 && remainingMemoryReq < memoryAsk) {
+		return 0
+	}
+
+	fit := freePods
+	if cpuAsk > 0 && remainingCPUReq/cpuAsk < fit {
+		fit = remainingCPUReq / cpuAsk
+	}
+	if memoryAsk > 0 && remainingMemoryReq/memoryAsk < fit {
+		fit = remainingMemoryReq / memoryAsk
+	}
+	return fit
+}
+
+// podKey returns the namespace/name of the pod.
+func podKey(p *v1.Pod) string {
+	return p.Namespace + "/" + p.Name
+}
+
+// isDaemonSetPod tells if the pod is controlled by a DaemonSet, it is not evicted by a drain.
+func isDaemonSetPod(p *v1.Pod) bool {
+	owner := metav1.GetControllerOf(p)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// isMirrorPod tells if the pod is a mirror of a static pod, kubelet runs it no matter what.
+func isMirrorPod(p *v1.Pod) bool {
+	_, ok := p.Annotations[v1.MirrorPodAnnotationKey]
+	return ok
+}
+
+// largestPod returns the CPU and memory requests of the largest single pod a node still
+// accepts, along with its free pod slots. A node out of pod slots accepts none.
+func largestPod(remainingCPUReq int64, remainingMemoryReq int64, freePods int64) (int64, int64, int64) {
+	if freePods <= 0 {
+		return 0, 0, 0
+	}
+	if remainingCPUReq < 0 {
+		remainingCPUReq = 0
+	}
+	if remainingMemoryReq < 0 {
+		remainingMemoryReq = 0
+	}
+	return remainingCPUReq, remainingMemoryReq, freePods
+}
+
+// schedulableOn tells if the pod spec may be scheduled on the node going by its node selector,
+// its required node affinity and its tolerations, the preferred node affinity does not bind.
+func schedulableOn(spec *v1.PodSpec, node *v1.Node) bool {
+	for key, value := range spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !matchesNodeSelector(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, node) {
+			return false
+		}
+	}
+
+taints:
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		for j := range spec.Tolerations {
+			if spec.Tolerations[j].ToleratesTaint(taint) {
+				continue taints
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// selectorOperators maps the node selector operators to the label selector ones.
+var selectorOperators = map[v1.NodeSelectorOperator]selection.Operator{
+	v1.NodeSelectorOpIn:           selection.In,
+	v1.NodeSelectorOpNotIn:        selection.NotIn,
+	v1.NodeSelectorOpExists:       selection.Exists,
+	v1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	v1.NodeSelectorOpGt:           selection.GreaterThan,
+	v1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// matchesNodeSelector tells if the node matches any of the terms of the node selector, a term
+// matching when all of its expressions on the labels and on the fields of the node do. As with
+// the scheduler, an empty term matches no node.
+func matchesNodeSelector(selector *v1.NodeSelector, node *v1.Node) bool {
+terms:
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		for _, expression := range term.MatchExpressions {
+			requirement, err := labels.NewRequirement(expression.Key, selectorOperators[expression.Operator], expression.Values)
+			if err != nil || !requirement.Matches(labels.Set(node.Labels)) {
+				continue terms
+			}
+		}
+		// metadata.name is the only field a node selector may go by, with In or NotIn.
+		for _, expression := range term.MatchFields {
+			named := containsString(expression.Values, node.Name)
+			switch {
+			case expression.Key != "metadata.name":
+				continue terms
+			case expression.Operator == v1.NodeSelectorOpIn && !named:
+				continue terms
+			case expression.Operator == v1.NodeSelectorOpNotIn && named:
+				continue terms
+			case expression.Operator != v1.NodeSelectorOpIn && expression.Operator != v1.NodeSelectorOpNotIn:
+				continue terms
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// podQOS returns the QoS class of the pod, the one in its status or else the one the kubelet
+// would give it: BestEffort without any requests or limits, Guaranteed when every container
+// has CPU and memory limits its requests are equal to, Burstable otherwise.
+func podQOS(p *v1.Pod) v1.PodQOSClass {
+	if p.Status.QOSClass != "" {
+		return p.Status.QOSClass
+	}
+
+	containers := append(append([]v1.Container{}, p.Spec.InitContainers...), p.Spec.Containers...)
+	bestEffort, guaranteed := true, true
+	for _, container := range containers {
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			request, requested := container.Resources.Requests[name]
+			limit, limited := container.Resources.Limits[name]
+			if (requested && !request.IsZero()) || (limited && !limit.IsZero()) {
+				bestEffort = false
+			}
+			// a request left out defaults to the limit.
+			if !limited || limit.IsZero() || (requested && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case bestEffort:
+		return v1.PodQOSBestEffort
+	case guaranteed:
+		return v1.PodQOSGuaranteed
+	default:
+		return v1.PodQOSBurstable
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestNodeFit(t *testing.T) {
+	tests := []struct {
+		name               string
+		remainingCPUReq    int64
+		remainingMemoryReq int64
+		cpuAsk             int64
+		memoryAsk          int64
+		freePods           int64
+		fit                int64
+	}{
+		{name: "exactly one pod left", remainingCPUReq: 1000, remainingMemoryReq: 2 * GIGABYTE, cpuAsk: 1000, memoryAsk: 2 * GIGABYTE, freePods: 50, fit: 1},
+		{name: "cpu and memory fit alike", remainingCPUReq: 3500, remainingMemoryReq: 7 * GIGABYTE, cpuAsk: 1000, memoryAsk: 2 * GIGABYTE, freePods: 50, fit: 3},
+		{name: "pod slots bind", remainingCPUReq: 100000, remainingMemoryReq: 100 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 100, fit: 100},
+		{name: "pod slots equal to the fit", remainingCPUReq: 4000, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 4, fit: 4},
+		{name: "cpu binds", remainingCPUReq: 2500, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 50, fit: 2},
+		{name: "memory binds", remainingCPUReq: 8000, remainingMemoryReq: 3 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 50, fit: 3},
+		{name: "short of cpu", remainingCPUReq: 500, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 50, fit: 0},
+		{name: "short of memory", remainingCPUReq: 8000, remainingMemoryReq: 512 * MEGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 50, fit: 0},
+		{name: "no pod slots", remainingCPUReq: 8000, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 0, fit: 0},
+		{name: "no cpu asked, memory binds", remainingCPUReq: 4000, remainingMemoryReq: 800 * GIGABYTE, cpuAsk: 0, memoryAsk: GIGABYTE, freePods: 110, fit: 110},
+		{name: "no cpu asked, memory binds below the pod slots", remainingCPUReq: 4000, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 0, memoryAsk: GIGABYTE, freePods: 110, fit: 8},
+		{name: "no memory asked, cpu binds", remainingCPUReq: 400000, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 100, memoryAsk: 0, freePods: 110, fit: 110},
+		{name: "no memory asked, cpu binds below the pod slots", remainingCPUReq: 1000, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 100, memoryAsk: 0, freePods: 110, fit: 10},
+		{name: "nothing asked", remainingCPUReq: 0, remainingMemoryReq: 0, cpuAsk: 0, memoryAsk: 0, freePods: 7, fit: 7},
+		{name: "no cpu asked on a node overcommitted on cpu", remainingCPUReq: -500, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 0, memoryAsk: GIGABYTE, freePods: 50, fit: 8},
+		{name: "overcommitted node", remainingCPUReq: -500, remainingMemoryReq: 8 * GIGABYTE, cpuAsk: 1000, memoryAsk: GIGABYTE, freePods: 50, fit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fit := nodeFit(tt.remainingCPUReq, tt.remainingMemoryReq, tt.cpuAsk, tt.memoryAsk, tt.freePods); fit != tt.fit {
+				t.Errorf("nodeFit = %d, want %d", fit, tt.fit)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// preemptionCandidate holds what a node has left and the pods it could give away
+// to the replicas of a workload with a higher priority. The host ports the replicas
+// bind cap the pods per node, whatever is preempted.
+type preemptionCandidate struct {
+	node                string
+	cordoned            bool
+	remainingCPUReq     int64
+	remainingMemoryReq  int64
+	remainingStorageReq int64
+	freePods            int64
+	portCap             int64
+	extendedAllocatable extendedResources
+	extendedReq         extendedResources
+	victims             []v1.Pod
+}
+
+// fit returns how many replicas fit into what the node has left, the way calculateCapacity counts
+// them: CPU, memory and the free pod slots, the ephemeral storage and the extended resources when
+// asked for, and the host ports.
+func (pc *preemptionCandidate) fit(cpu int64, memory int64, storage int64, freePods int64, extendedReq extendedResources, cpuAsk int64, memoryAsk int64, storageAsk int64, extendedAsk extendedResources) int64 {
+	fit := nodeFit(cpu, memory, cpuAsk, memoryAsk, freePods)
+	if storageAsk > 0 && storage/storageAsk < fit {
+		fit = storage / storageAsk
+	}
+	if pc.portCap < fit {
+		fit = pc.portCap
+	}
+	if extendedFit, _ := extendedSpinable(pc.extendedAllocatable, extendedReq, extendedAsk, freePods); extendedFit < fit {
+		fit = extendedFit
+	}
+	if fit < 0 {
+		return 0
+	}
+	return fit
+}
+
+// getPriority returns the value of the priority class and if the pods using it are allowed to preempt.
+func getPriority(c *k8s.Clientset, priorityClass string) (int32, bool) {
+	pc, err := c.SchedulingV1().PriorityClasses().Get(priorityClass, metav1.GetOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting priority class!!")
+		panic(err.Error())
+	}
+
+	// pods preempt lower priority pods, unless the priority class says they never should.
+	preempts := pc.PreemptionPolicy == nil || *pc.PreemptionPolicy != v1.PreemptNever
+	return pc.Value, preempts
+}
+
+// podPriority returns the priority of the pod, pods without one have the default priority of zero.
+func podPriority(p *v1.Pod) int32 {
+	if p.Spec.Priority == nil {
+		return 0
+	}
+	return *p.Spec.Priority
+}
+
+// preemptionVictims returns the pods with a lower priority than given, in the
+// order they would be preempted, the lowest priority first.
+func preemptionVictims(pods []v1.Pod, priority int32) []v1.Pod {
+	victims := make([]v1.Pod, 0, len(pods))
+	for _, p := range pods {
+		if podPriority(&p) < priority {
+			victims = append(victims, p)
+		}
+	}
+	sort.SliceStable(victims, func(i, j int) bool {
+		return podPriority(&victims[i]) < podPriority(&victims[j])
+	})
+	return victims
+}
+
+// planPreemption walks the schedulable nodes and preempts their victims one after
+// another until the needed replicas find room. A victim is picked only if, along with
+// the ones before it, it frees enough for at least one more replica. It returns the
+// number of replicas placed this way and the pods which would be preempted for them.
+func planPreemption(candidates []preemptionCandidate, cpuAsk int64, memoryAsk int64, storageAsk int64, extendedAsk extendedResources, needed int64) (int64, []string) {
+	placed := int64(0)
+	preempted := make([]string, 0, 3)
+
+	for c := range candidates {
+		pc := &candidates[c]
+		if pc.cordoned {
+			continue
+		}
+		cpu, memory, storage, freePods := pc.remainingCPUReq, pc.remainingMemoryReq, pc.remainingStorageReq, pc.freePods
+		extendedReq := make(extendedResources, len(pc.extendedReq))
+		for name, value := range pc.extendedReq {
+			extendedReq[name] = value
+		}
+		fit := pc.fit(cpu, memory, storage, freePods, extendedReq, cpuAsk, memoryAsk, storageAsk, extendedAsk)
+		committed := 0
+
+		for i := 0; i < len(pc.victims) && placed < needed; i++ {
+			victimCPU, victimMemory := podRequests(&pc.victims[i])
+			cpu, memory, freePods = cpu+victimCPU, memory+victimMemory, freePods+1
+			victimRequests := effectiveResources(&pc.victims[i].Spec, requestsOf)
+			storage += victimRequests.StorageEphemeral().Value()
+			for name, value := range podExtendedRequests(&pc.victims[i].Spec) {
+				extendedReq[name] -= value
+			}
+
+			if more := pc.fit(cpu, memory, storage, freePods, extendedReq, cpuAsk, memoryAsk, storageAsk, extendedAsk); more > fit {
+				for _, victim := range pc.victims[committed : i+1] {
+					preempted = append(preempted, fmt.Sprintf("%s/%s (%s, priority %d)", victim.Namespace, victim.Name, pc.node, podPriority(&victim)))
+				}
+				placed += more - fit
+				fit, committed = more, i+1
+			}
+		}
+	}
+
+	return placed, preempted
+}
+
+// printPreemption prints the replicas which fit with and without preempting lower priority pods.
+func printPreemption(w *tabwriter.Writer, priorityClass string, preempts bool, candidates []preemptionCandidate, cpuAsk int64, memoryAsk int64, storageAsk int64, extendedAsk extendedResources, replicaAsk int, netReplicas int64) {
+
+	withPreemption, preempted := int64(0), make([]string, 0)
+	if preempts {
+		// all the lower priority pods out of the way give the most the cluster could fit.
+		withPreemption, _ = planPreemption(candidates, cpuAsk, memoryAsk, storageAsk, extendedAsk, math.MaxInt64)
+		if netReplicas < int64(replicaAsk) {
+			_, preempted = planPreemption(candidates, cpuAsk, memoryAsk, storageAsk, extendedAsk, int64(replicaAsk)-netReplicas)
+		}
+	}
+	withPreemption += netReplicas
+
+	Rows(w, "%s\t%s\t%s\t%t\n", "Priority Class via STDIN: ", priorityClass, "Can Preempt?: ", preempts)
+	Rows(w, "%s\t%d\t%s\t%d\n", "Fits Without Preemption: ", netReplicas, "Fits With Preemption: ", withPreemption)
+	if withPreemption >= int64(replicaAsk) {
+		Rows(w, "%s\t%s\t\n", "Is Scheduleable With Preemption?: ", "True")
+	} else {
+		Rows(w, "%s\t%s\t\n", "Is Scheduleable With Preemption?: ", "False")
+	}
+	Rows(w, "%s\t%d\t\n", "Pods To Be Preempted: ", len(preempted))
+	Rows(w, "%s\t%s\t", "Preempted Pods List: ", VPrint(preempted))
+	Columns(w, "\n")
+}
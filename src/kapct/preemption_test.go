@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// priorityPod returns a pod of the priority given, with a container of the requests given.
+func priorityPod(name string, priority int32, requests v1.ResourceList) v1.Pod {
+	p := testPod("shop", name, "web", requests)
+	p.Spec.Priority = &priority
+	return p
+}
+
+func TestPreemptionVictims(t *testing.T) {
+	pods := []v1.Pod{
+		priorityPod("batch-1", 1, resources("500m", "512Mi")),
+		priorityPod("critical", 5, resources("500m", "512Mi")),
+		priorityPod("best-effort", 0, resources("1", "1Gi")),
+		priorityPod("batch-2", 1, resources("500m", "512Mi")),
+		priorityPod("equal", 3, resources("500m", "512Mi")),
+	}
+
+	victims := make([]string, 0, len(pods))
+	for _, p := range preemptionVictims(pods, 3) {
+		victims = append(victims, p.Name)
+	}
+	// the lowest priority first, the pods of the same priority in the order they run.
+	want := []string{"best-effort", "batch-1", "batch-2"}
+	if !reflect.DeepEqual(victims, want) {
+		t.Errorf("victims = %v, want %v", victims, want)
+	}
+}
+
+func TestPlanPreemption(t *testing.T) {
+	victims := preemptionVictims([]v1.Pod{
+		priorityPod("batch-1", 1, resources("500m", "512Mi")),
+		priorityPod("best-effort", 0, resources("1", "1Gi")),
+		priorityPod("critical", 5, resources("2", "2Gi")),
+	}, 3)
+	candidate := func(node string, cordoned bool) preemptionCandidate {
+		return preemptionCandidate{
+			node:                node,
+			cordoned:            cordoned,
+			remainingCPUReq:     500,
+			remainingMemoryReq:  4 * GIGABYTE,
+			freePods:            10,
+			portCap:             110,
+			extendedAllocatable: extendedResources{},
+			extendedReq:         extendedResources{},
+			victims:             victims,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []preemptionCandidate
+		needed     int64
+		placed     int64
+		preempted  []string
+	}{
+		{
+			name:       "the lowest priority victim frees enough for one",
+			candidates: []preemptionCandidate{candidate("worker-1", false)},
+			needed:     1,
+			placed:     1,
+			preempted:  []string{"shop/best-effort (worker-1, priority 0)"},
+		},
+		{
+			name:       "all the victims out of the way",
+			candidates: []preemptionCandidate{candidate("worker-1", false)},
+			needed:     math.MaxInt64,
+			placed:     2,
+			preempted:  []string{"shop/best-effort (worker-1, priority 0)", "shop/batch-1 (worker-1, priority 1)"},
+		},
+		{
+			name:       "cordoned nodes preempt nothing",
+			candidates: []preemptionCandidate{candidate("worker-1", true), candidate("worker-2", false)},
+			needed:     1,
+			placed:     1,
+			preempted:  []string{"shop/best-effort (worker-2, priority 0)"},
+		},
+		{
+			name:       "nothing needed",
+			candidates: []preemptionCandidate{candidate("worker-1", false)},
+			needed:     0,
+			placed:     0,
+			preempted:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placed, preempted := planPreemption(tt.candidates, 1000, GIGABYTE, 0, extendedResources{}, tt.needed)
+			if placed != tt.placed {
+				t.Errorf("placed = %d, want %d", placed, tt.placed)
+			}
+			if !reflect.DeepEqual(preempted, tt.preempted) {
+				t.Errorf("preempted = %v, want %v", preempted, tt.preempted)
+			}
+		})
+	}
+}
+
+func TestPlanPreemptionExtendedOfInitContainers(t *testing.T) {
+	// the victim holds the GPU through its init container, preempting it frees the GPU.
+	victim := priorityPod("trainer", 0, resources("100m", "128Mi"))
+	victim.Spec.InitContainers = []v1.Container{{Name: "warmup", Resources: v1.ResourceRequirements{
+		Limits: v1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+	}}}
+	candidates := []preemptionCandidate{{
+		node:                "worker-1",
+		remainingCPUReq:     4000,
+		remainingMemoryReq:  4 * GIGABYTE,
+		freePods:            10,
+		portCap:             110,
+		extendedAllocatable: extendedResources{"nvidia.com/gpu": 1},
+		extendedReq:         podExtendedRequests(&victim.Spec),
+		victims:             []v1.Pod{victim},
+	}}
+
+	placed, preempted := planPreemption(candidates, 100, 128*MEGABYTE, 0, extendedResources{"nvidia.com/gpu": 1}, 1)
+	if placed != 1 || len(preempted) != 1 {
+		t.Errorf("placed %d replicas preempting %v, want 1 replica preempting the trainer", placed, preempted)
+	}
+}
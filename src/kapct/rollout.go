@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// rolloutCheck tells if the cluster can take the update of a Deployment to a new pod
+// template. The rollout is walked step by step, the way the Deployment controller
+// scales the new pods up and the old pods down, placing the new pods on the nodes.
+func rolloutCheck(args []string) {
+	var namespace string
+	var workloadFile string
+
+	f := flag.NewFlagSet("rollout-check", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&namespace, "n", "default", "namespace of the deployment.")
+	f.StringVar(&workloadFile, "f", "", "workload definition file with the new pod template.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct rollout-check deployment/name [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+
+	kind, name := parseReference(parseReferenceArgs(f, args))
+	if kind != "deployment" || workloadFile == "" {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+
+	deployment, err := c.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting deployment!!")
+		panic(err.Error())
+	}
+	template, err := readWorkload(workloadFile)
+	if err != nil {
+		fmt.Println("There is a problem reading workload definition!!")
+		panic(err.Error())
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		fmt.Println("There is a problem reading deployment selector!!")
+		panic(err.Error())
+	}
+
+	replicas := 1
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+	surge, unavailable := rolloutBounds(deployment.Spec.Strategy, replicas)
+
+	workers := getWorkerNodes(c)
+	oldPods := make([]podMove, 0, replicas)
+	for _, n := range workers {
+		for _, p := range n.pods {
+			if p.Namespace == namespace && selector.Matches(labels.Set(p.Labels)) {
+				oldPods = append(oldPods, podMove{pod: p, from: n.name})
+			}
+		}
+	}
+
+	newPod := v1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec}
+	newPod.Namespace = namespace
+
+	// walk the rollout, new pods are assumed available as soon as they are placed.
+	oldCPU, oldMemory := specRequests(&deployment.Spec.Template.Spec)
+	newCPU, newMemory := podRequests(&newPod)
+	old, updated, step, stalledAt := len(oldPods), 0, 0, 0
+	peakCPU, peakMemory, unschedulable := int64(0), int64(0), make([]string, 0, 3)
+
+	for stalledAt == 0 && (updated < replicas || old > 0) {
+		step++
+		progressed := false
+
+		// scale the new pods up as far as max surge allows.
+		if up := minInt(replicas+surge-old-updated, replicas-updated); up > 0 {
+			moves := make([]podMove, 0, up)
+			for i := 0; i < up; i++ {
+				p := *newPod.DeepCopy()
+				p.Name = fmt.Sprintf("%s-new-%d", name, updated+i+1)
+				moves = append(moves, podMove{pod: p})
+			}
+			for _, move := range reschedulePods(moves, workers) {
+				if move.to == "" {
+					unschedulable = append(unschedulable, fmt.Sprintf("%s (step %d)", move.pod.Name, step))
+					stalledAt = step
+				}
+			}
+			updated += up
+			progressed = true
+		}
+
+		extraCPU := int64(old)*oldCPU + int64(updated)*newCPU - int64(replicas)*oldCPU
+		extraMemory := int64(old)*oldMemory + int64(updated)*newMemory - int64(replicas)*oldMemory
+		if extraCPU > peakCPU {
+			peakCPU = extraCPU
+		}
+		if extraMemory > peakMemory {
+			peakMemory = extraMemory
+		}
+
+		// scale the old pods down as far as max unavailable allows.
+		if down := minInt(old+updated-(replicas-unavailable), old); down > 0 {
+			for _, move := range oldPods[old-down : old] {
+				findNode(workers, move.from).evict(&move.pod)
+			}
+			old -= down
+			progressed = true
+		}
+
+		if !progressed {
+			break
+		}
+	}
+
+	headroomCPU, headroomMemory := int64(0), int64(0)
+	for _, n := range workers {
+		if !n.node.Spec.Unschedulable {
+			cpu, memory, _ := n.remaining()
+			headroomCPU, headroomMemory = headroomCPU+cpu, headroomMemory+memory
+		}
+	}
+
+	Columns(w, "\n")
+	Rows(w, "%s\t%s\t%s\t%d\n", "Deployment: ", namespace+"/"+name, "Replicas: ", replicas)
+	Rows(w, "%s\t%s\t%s\t%d\n", "Strategy: ", string(deployment.Spec.Strategy.Type), "Rollout Steps: ", step)
+	Rows(w, "%s\t%d\t%s\t%d\n", "Max Surge: ", surge, "Max Unavailable: ", unavailable)
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "Old Pod CPU Request: ", oldCPU, "Old Pod Memory Request: ", oldMemory/MEGABYTE)
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "New Pod CPU Request: ", newCPU, "New Pod Memory Request: ", newMemory/MEGABYTE)
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "Peak Extra CPU Request: ", peakCPU, "Peak Extra Memory Request: ", peakMemory/MEGABYTE)
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "CPU Headroom After Rollout: ", headroomCPU, "Memory Headroom After Rollout: ", headroomMemory/MEGABYTE)
+	Columns(w, "\n")
+	if stalledAt == 0 {
+		Rows(w, "%s\t%s\t\n", "Does Surge Fit?: ", "True")
+	} else {
+		Rows(w, "%s\t%s\t%s\t%d\n", "Does Surge Fit?: ", "False", "Rollout Stalls At Step: ", stalledAt)
+		Rows(w, "%s\t%s\t", "Unschedulable New Pods List: ", VPrint(unschedulable))
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
+
+// rolloutBounds returns how many pods the rollout may create over and take down below the
+// replicas, the same way the Deployment controller resolves the percentages.
+func rolloutBounds(strategy appsv1.DeploymentStrategy, replicas int) (int, int) {
+	if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return 0, replicas
+	}
+
+	defaultBound := intstr.FromString("25%")
+	maxSurge, maxUnavailable := &defaultBound, &defaultBound
+	if strategy.RollingUpdate != nil {
+		if strategy.RollingUpdate.MaxSurge != nil {
+			maxSurge = strategy.RollingUpdate.MaxSurge
+		}
+		if strategy.RollingUpdate.MaxUnavailable != nil {
+			maxUnavailable = strategy.RollingUpdate.MaxUnavailable
+		}
+	}
+
+	surge, err := intstr.GetValueFromIntOrPercent(maxSurge, replicas, true)
+	if err != nil {
+		fmt.Println("There is a problem reading max surge!!")
+		panic(err.Error())
+	}
+	unavailable, err := intstr.GetValueFromIntOrPercent(maxUnavailable, replicas, false)
+	if err != nil {
+		fmt.Println("There is a problem reading max unavailable!!")
+		panic(err.Error())
+	}
+
+	// the controller does not let both be zero, it could never make progress.
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+	return surge, unavailable
+}
+
+// minInt returns the smaller of the two.
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
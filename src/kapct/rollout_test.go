@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRolloutBounds(t *testing.T) {
+	bound := func(value intstr.IntOrString) *intstr.IntOrString { return &value }
+
+	tests := []struct {
+		name        string
+		strategy    appsv1.DeploymentStrategy
+		replicas    int
+		surge       int
+		unavailable int
+	}{
+		{name: "recreate takes all the replicas down", strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}, replicas: 4, surge: 0, unavailable: 4},
+		{name: "defaults round surge up and unavailable down", replicas: 10, surge: 3, unavailable: 2},
+		{name: "defaults of a single replica", replicas: 1, surge: 1, unavailable: 0},
+		{
+			name:        "absolute bounds",
+			strategy:    appsv1.DeploymentStrategy{RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: bound(intstr.FromInt(2)), MaxUnavailable: bound(intstr.FromInt(1))}},
+			replicas:    10,
+			surge:       2,
+			unavailable: 1,
+		},
+		{
+			name:        "percentages",
+			strategy:    appsv1.DeploymentStrategy{RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: bound(intstr.FromString("50%")), MaxUnavailable: bound(intstr.FromString("50%"))}},
+			replicas:    5,
+			surge:       3,
+			unavailable: 2,
+		},
+		{
+			name:        "surge left out defaults",
+			strategy:    appsv1.DeploymentStrategy{RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: bound(intstr.FromInt(0))}},
+			replicas:    8,
+			surge:       2,
+			unavailable: 0,
+		},
+		{
+			name:        "both zero still takes one down",
+			strategy:    appsv1.DeploymentStrategy{RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: bound(intstr.FromInt(0)), MaxUnavailable: bound(intstr.FromString("10%"))}},
+			replicas:    3,
+			surge:       0,
+			unavailable: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			surge, unavailable := rolloutBounds(tt.strategy, tt.replicas)
+			if surge != tt.surge || unavailable != tt.unavailable {
+				t.Errorf("rolloutBounds = %d, %d, want %d, %d", surge, unavailable, tt.surge, tt.unavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// scaleCheck tells if a live Deployment, StatefulSet or ReplicaSet can be scaled to the
+// replicas asked for. The resources are taken from the pod template of the workload and
+// the additional replicas are checked the same way as the ones asked for via STDIN.
+func scaleCheck(args []string) {
+	var namespace string
+	var to int
+
+	f := flag.NewFlagSet("scale-check", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&namespace, "n", "default", "namespace of the workload.")
+	f.IntVar(&to, "to", 0, "number of replicas you want to scale the workload to.")
+	thresholds := overcommitFlags(f)
+	namespaceSwitches := namespaceFlags(f, true)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct scale-check deployment|statefulset|replicaset/name [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+
+	kind, name := parseReference(parseReferenceArgs(f, args))
+	if name == "" || to < 1 {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	namespaces, err := namespaceSwitches.filter()
+	if err != nil {
+		fmt.Println("There is a problem parsing exempt-selector!!")
+		panic(err.Error())
+	}
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+
+	template, running, err := getWorkloadTemplate(c, kind, namespace, name)
+	if err != nil {
+		fmt.Println("There is a problem getting workload!!")
+		panic(err.Error())
+	}
+
+	Columns(w, "\n")
+	Rows(w, "%s\t%s\t%s\t%s\n", "Workload: ", kind+"/"+name, "Namespace: ", namespace)
+	Rows(w, "%s\t%d\t%s\t%d\n", "Running Replicas: ", running, "Desired Replicas: ", to)
+
+	additional := to - int(running)
+	if additional <= 0 {
+		Rows(w, "%s\t%d\t%s\t%s\n", "Additional Replicas: ", 0, "Is Scheduleable?: ", "True")
+		Columns(w, "\n")
+		w.Flush()
+		return
+	}
+	Rows(w, "%s\t%d\t\n", "Additional Replicas: ", additional)
+	w.Flush()
+
+	// the running replicas already take their share on the nodes, only the additional ones are asked for.
+	requests := effectiveResources(&template.Spec, requestsOf)
+	limits := effectiveResources(&template.Spec, limitsOf)
+	extendedAsk := make(extendedResources)
+	addExtendedRequests(extendedAsk, v1.ResourceRequirements{Requests: requests, Limits: limits})
+
+	getNodeResources(w, c,
+		fmt.Sprintf("%dm", requests.Cpu().MilliValue()),
+		FromBytes(requests.Memory().Value()),
+		fmt.Sprintf("%dm", limits.Cpu().MilliValue()),
+		FromBytes(limits.Memory().Value()),
+		FromBytes(requests.StorageEphemeral().Value()),
+		FromBytes(limits.StorageEphemeral().Value()),
+		additional,
+		extendedAsk,
+		wantedHostPorts(&template.Spec),
+		template.Spec.PriorityClassName,
+		false,
+		nodeEdits{},
+		nil,
+		thresholds,
+		namespaces)
+}
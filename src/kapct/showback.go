@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// usageGroup holds the requests and limits of the pods of a namespace, or of a label value.
+type usageGroup struct {
+	name        string
+	pods        int
+	cpuReq      int64
+	cpuLimit    int64
+	memoryReq   int64
+	memoryLimit int64
+}
+
+// showback reports who is using the cluster, the requests and limits of the pods on the
+// worker nodes per namespace and per value of the labels given, e.g. team or cost-center.
+// A pod without the label is counted by the label of its namespace.
+func showback(args []string) {
+	var labelList string
+	var csvFile string
+
+	f := flag.NewFlagSet("showback", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&labelList, "labels", "", "(optional) comma separated labels to group the pods by, besides the namespace, e.g. team,cost-center.")
+	f.StringVar(&csvFile, "csv", "", "(optional) file to export the report to as CSV.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct showback [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+	workers := getWorkerNodes(c)
+
+	namespaces, err := c.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("There is a problem getting namespaces!!")
+		panic(err.Error())
+	}
+	namespaceLabels := make(map[string]map[string]string)
+	for _, ns := range namespaces.Items {
+		namespaceLabels[ns.Name] = ns.Labels
+	}
+
+	labelKeys := make([]string, 0, 3)
+	for _, key := range strings.Split(labelList, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			labelKeys = append(labelKeys, key)
+		}
+	}
+
+	cpuAllocatable, memoryAllocatable := int64(0), int64(0)
+	byNamespace, byLabel := make(map[string]*usageGroup), make(map[string]map[string]*usageGroup)
+	for _, key := range labelKeys {
+		byLabel[key] = make(map[string]*usageGroup)
+	}
+	for _, n := range workers {
+		cpuAllocatable, memoryAllocatable = cpuAllocatable+n.cpuAllocatable, memoryAllocatable+n.memoryAllocatable
+		for i := range n.pods {
+			p := &n.pods[i]
+			requests, limits := effectiveResources(&p.Spec, requestsOf), effectiveResources(&p.Spec, limitsOf)
+			add := func(groups map[string]*usageGroup, name string) {
+				group, ok := groups[name]
+				if !ok {
+					group = &usageGroup{name: name}
+					groups[name] = group
+				}
+				group.pods++
+				group.cpuReq += requests.Cpu().MilliValue()
+				group.cpuLimit += limits.Cpu().MilliValue()
+				group.memoryReq += requests.Memory().Value()
+				group.memoryLimit += limits.Memory().Value()
+			}
+
+			add(byNamespace, p.Namespace)
+			for _, key := range labelKeys {
+				value, ok := p.Labels[key]
+				if !ok {
+					value, ok = namespaceLabels[p.Namespace][key]
+				}
+				if !ok {
+					value = "<none>"
+				}
+				add(byLabel[key], value)
+			}
+		}
+	}
+
+	sections := []string{"namespace"}
+	groups := map[string][]usageGroup{"namespace": sortedGroups(byNamespace)}
+	for _, key := range labelKeys {
+		sections = append(sections, key)
+		groups[key] = sortedGroups(byLabel[key])
+	}
+
+	for _, section := range sections {
+		printShowback(w, section, groups[section], cpuAllocatable, memoryAllocatable)
+	}
+	Rows(w, "%s\t%d\t%s\t%d\n", "Number of worker nodes: ", len(workers), "Number of Namespaces: ", len(byNamespace))
+	Rows(w, "%s\t%dm\t%s\t%dM\n", "Cluster CPU Allocatable: ", cpuAllocatable, "Cluster Memory Allocatable: ", memoryAllocatable/MEGABYTE)
+	Columns(w, "\n")
+	w.Flush()
+
+	if csvFile != "" {
+		if err := writeShowbackCSV(csvFile, sections, groups, cpuAllocatable, memoryAllocatable); err != nil {
+			fmt.Println("There is a problem writing CSV file!!")
+			panic(err.Error())
+		}
+	}
+}
+
+// sortedGroups returns the groups, the ones requesting the most CPU first.
+func sortedGroups(groups map[string]*usageGroup) []usageGroup {
+	sorted := make([]usageGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].cpuReq != sorted[j].cpuReq {
+			return sorted[i].cpuReq > sorted[j].cpuReq
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// printShowback prints the requests and limits of the groups of a section, along with the
+// part of the cluster allocatable they request.
+func printShowback(p *tabwriter.Writer, section string, groups []usageGroup, cpuAllocatable int64, memoryAllocatable int64) {
+	// the section is the namespace or a label key, capitalised for the title and the column.
+	title := strings.ToUpper(section[:1]) + section[1:]
+	Columns(p, "\n")
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%40s\t\n", "Showback Per "+title)
+	Rows(p, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(p, "%-11s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t%-5s\t\n", title, "Pods", "CpuReq", "CpuLimit", "MemReq", "MemLimit", "CpuReq%", "MemReq%")
+	for _, group := range groups {
+		Rows(p, "%2s\t%4d\t%5dm\t%7dm\t%5dM\t%7dM\t%6.2f%%\t%6.2f%%\t\n", group.name, group.pods, group.cpuReq, group.cpuLimit, group.memoryReq/MEGABYTE, group.memoryLimit/MEGABYTE, percentOf(group.cpuReq, cpuAllocatable), percentOf(group.memoryReq, memoryAllocatable))
+	}
+	Columns(p, "\n")
+}
+
+// writeShowbackCSV exports the groups of every section to the CSV file, one row per group.
+func writeShowbackCSV(path string, sections []string, groups map[string][]usageGroup, cpuAllocatable int64, memoryAllocatable int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out := csv.NewWriter(file)
+	out.Write([]string{"group_by", "name", "pods", "cpu_request_m", "cpu_limit_m", "memory_request_bytes", "memory_limit_bytes", "cpu_request_percent", "memory_request_percent"})
+	for _, section := range sections {
+		for _, group := range groups[section] {
+			out.Write([]string{
+				section,
+				group.name,
+				strconv.Itoa(group.pods),
+				strconv.FormatInt(group.cpuReq, 10),
+				strconv.FormatInt(group.cpuLimit, 10),
+				strconv.FormatInt(group.memoryReq, 10),
+				strconv.FormatInt(group.memoryLimit, 10),
+				strconv.FormatFloat(percentOf(group.cpuReq, cpuAllocatable), 'f', 2, 64),
+				strconv.FormatFloat(percentOf(group.memoryReq, memoryAllocatable), 'f', 2, 64),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/yaml"
+)
+
+// the least requests suggested for a container, and the ones suggested without anything to go by.
+const (
+	minCPURequest     = 10
+	minMemoryRequest  = 16 * MEGABYTE
+	fallbackCPU       = 100
+	fallbackMemoryReq = 128 * MEGABYTE
+)
+
+// resourceSamples holds the CPU(in milicores) and memory(in bytes) seen, requested or used.
+type resourceSamples struct {
+	cpu    []int64
+	memory []int64
+}
+
+// add adds the CPU and memory to the samples, leaving out the ones not defined.
+func (s *resourceSamples) add(cpu int64, memory int64) {
+	if cpu > 0 {
+		s.cpu = append(s.cpu, cpu)
+	}
+	if memory > 0 {
+		s.memory = append(s.memory, memory)
+	}
+}
+
+// undefinedContainer is a container of a workload missing its CPU or memory request, or both,
+// along with whether it misses the limits as well.
+type undefinedContainer struct {
+	workloadContainer
+	cpu           bool
+	memory        bool
+	cpuNoLimit    bool
+	memoryNoLimit bool
+}
+
+// suggest proposes a LimitRange for the namespace, its default requests and limits, as YAML ready
+// to apply, along with the kubectl patch commands adding the requests of the containers which
+// have none to the pod templates of their workloads. The values come from
+// the requests and limits of the containers of the namespace which define them, or from the
+// usage of the containers from the metrics.k8s.io API with -metrics, at the given percentile.
+func suggest(args []string) {
+	var namespace string
+	var withMetrics bool
+	var percentile float64
+	var limitRatio float64
+
+	f := flag.NewFlagSet("suggest", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.StringVar(&namespace, "n", "", "namespace to suggest the LimitRange and the requests for.")
+	f.BoolVar(&withMetrics, "metrics", false, "(optional) suggest the requests from the usage from the metrics.k8s.io API, from the requests defined in the namespace otherwise.")
+	f.Float64Var(&percentile, "percentile", 90, "percentile of the requests, or of the usage, the suggestions are taken at.")
+	f.Float64Var(&limitRatio, "limit-ratio", 2, "ratio of the default limits to the default requests, when no container of the namespace defines limits.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct suggest -n namespace [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if namespace == "" || percentile <= 0 || percentile > 100 || limitRatio < 1 {
+		f.Usage()
+		os.Exit(2)
+	}
+
+	c := newClientSet(*kubeconfig)
+	fieldSelector, err := fields.ParseSelector("status.phase!=" + "Succeeded" + ",status.phase!=" + "Failed")
+	if err != nil {
+		fmt.Println("There is a problem setting filters!!")
+		panic(err.Error())
+	}
+	pods, err := c.CoreV1().Pods(namespace).List(metav1.ListOptions{FieldSelector: fieldSelector.String()})
+	if err != nil {
+		fmt.Println("There is a problem getting pods!!")
+		panic(err.Error())
+	}
+
+	containerUsage := make(map[string]v1.ResourceList)
+	if withMetrics {
+		containerUsage = getContainerMetrics(newMetricsClientSet(*kubeconfig), namespace)
+	}
+	owners := getWorkloadOwners(c, namespace)
+
+	// the requests and limits defined in the namespace, the requests of the containers without
+	// limits, the usage of all of its containers and of each workload container.
+	requests, limits, unlimited, used := resourceSamples{}, resourceSamples{}, resourceSamples{}, resourceSamples{}
+	usedBy := make(map[workloadContainer]*resourceSamples)
+	undefined := make(map[workloadContainer]*undefinedContainer)
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		kind, name := owningWorkload(p, owners)
+		for _, container := range p.Spec.Containers {
+			resources := container.Resources
+			cpuReq, memoryReq := resources.Requests.Cpu().MilliValue(), resources.Requests.Memory().Value()
+			cpuLimit, memoryLimit := resources.Limits.Cpu().MilliValue(), resources.Limits.Memory().Value()
+			requests.add(cpuReq, memoryReq)
+			limits.add(cpuLimit, memoryLimit)
+			if cpuLimit == 0 {
+				unlimited.add(cpuReq, 0)
+			}
+			if memoryLimit == 0 {
+				unlimited.add(0, memoryReq)
+			}
+
+			key := workloadContainer{Namespace: p.Namespace, Kind: kind, Workload: name, Container: container.Name}
+			if usage, ok := containerUsage[podKey(p)+"/"+container.Name]; ok {
+				used.add(usage.Cpu().MilliValue(), usage.Memory().Value())
+				if usedBy[key] == nil {
+					usedBy[key] = &resourceSamples{}
+				}
+				usedBy[key].add(usage.Cpu().MilliValue(), usage.Memory().Value())
+			}
+
+			if cpuReq == 0 || memoryReq == 0 {
+				if undefined[key] == nil {
+					undefined[key] = &undefinedContainer{workloadContainer: key}
+				}
+				undefined[key].cpu = undefined[key].cpu || cpuReq == 0
+				undefined[key].memory = undefined[key].memory || memoryReq == 0
+				undefined[key].cpuNoLimit = undefined[key].cpuNoLimit || cpuLimit == 0
+				undefined[key].memoryNoLimit = undefined[key].memoryNoLimit || memoryLimit == 0
+			}
+		}
+	}
+
+	source := requests
+	if withMetrics {
+		source = used
+	}
+	defaultCPU := suggestedRequest(source.cpu, percentile, fallbackCPU, minCPURequest)
+	defaultMemory := suggestedRequest(source.memory, percentile, fallbackMemoryReq, minMemoryRequest)
+
+	// the containers of a workload go in one strategic merge patch, merged into the containers of
+	// its pod template by name. The workloads without a template to patch are only listed.
+	keys := make([]workloadContainer, 0, len(undefined))
+	for key := range undefined {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessContainer(keys[i], keys[j]) })
+
+	patches := make(map[string]map[string]interface{})
+	order, unpatched := make([]workloadContainer, 0, 3), make([]string, 0, 3)
+	for _, key := range keys {
+		cpu, memory := defaultCPU, defaultMemory
+		if samples, ok := usedBy[key]; ok {
+			cpu = suggestedRequest(samples.cpu, percentile, defaultCPU, minCPURequest)
+			memory = suggestedRequest(samples.memory, percentile, defaultMemory, minMemoryRequest)
+		}
+		containerRequests := map[string]string{}
+		if undefined[key].cpu {
+			containerRequests["cpu"] = cpuQuantity(cpu)
+			if undefined[key].cpuNoLimit {
+				unlimited.add(cpu, 0)
+			}
+		}
+		if undefined[key].memory {
+			containerRequests["memory"] = memoryQuantity(memory)
+			if undefined[key].memoryNoLimit {
+				unlimited.add(0, roundedMemory(memory))
+			}
+		}
+
+		id := key.Kind + "/" + key.Workload
+		if !patchableKinds[key.Kind] {
+			unpatched = append(unpatched, fmt.Sprintf("%s/%s: cpu %s, memory %s", id, key.Container, cpuQuantity(cpu), memoryQuantity(memory)))
+			continue
+		}
+		patch, ok := patches[id]
+		if !ok {
+			patch = make(map[string]interface{})
+			patches[id] = patch
+			order = append(order, key)
+		}
+		addContainerRequests(patch, key.Kind, key.Container, containerRequests)
+	}
+
+	limitCPU := defaultLimit(limits.cpu, unlimited.cpu, percentile, defaultCPU, limitRatio)
+	limitMemory := defaultLimit(limits.memory, unlimited.memory, percentile, defaultMemory, limitRatio)
+
+	from := "the requests defined"
+	if withMetrics {
+		from = "the usage"
+	}
+	fmt.Printf("# suggested by kapct from %s in namespace %s at the %gth percentile,\n", from, namespace, percentile)
+	fmt.Printf("# %d containers without requests, apply the LimitRange with: kubectl apply -f <file>,\n", len(keys))
+	fmt.Println("# and patch the workloads with the kubectl patch commands after it.")
+	data, err := yaml.Marshal(limitRangeDocument(namespace, defaultCPU, defaultMemory, limitCPU, limitMemory))
+	if err != nil {
+		fmt.Println("There is a problem writing YAML!!")
+		panic(err.Error())
+	}
+	fmt.Print(string(data))
+	for _, key := range order {
+		data, err := json.Marshal(patches[key.Kind+"/"+key.Workload])
+		if err != nil {
+			fmt.Println("There is a problem writing patch!!")
+			panic(err.Error())
+		}
+		fmt.Printf("# kubectl patch %s/%s -n %s --type strategic -p '%s'\n", strings.ToLower(key.Kind), key.Workload, key.Namespace, data)
+	}
+	for _, container := range unpatched {
+		fmt.Printf("# no workload template to patch, %s\n", container)
+	}
+}
+
+// suggestedRequest returns the percentile of the samples, the fallback without any, and no less than the least given.
+func suggestedRequest(samples []int64, percentile float64, fallback int64, least int64) int64 {
+	value := fallback
+	if len(samples) > 0 {
+		sorted := append([]int64(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		// nearest rank, the smallest sample at or above the percentile.
+		rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		value = sorted[rank-1]
+	}
+	if value < least {
+		value = least
+	}
+	return value
+}
+
+// defaultLimit returns the default limit at the percentile of the limits, ratio times the default
+// request without any. The default limit goes to the containers without limits, so it is no less
+// than the largest of their requests, a limit below the request keeps a pod from being admitted.
+func defaultLimit(limits []int64, unlimitedRequests []int64, percentile float64, request int64, ratio float64) int64 {
+	limit := suggestedRequest(limits, percentile, int64(float64(request)*ratio), request)
+	for _, unlimited := range unlimitedRequests {
+		if unlimited > limit {
+			limit = unlimited
+		}
+	}
+	return limit
+}
+
+// cpuQuantity formats the milicores as a Kubernetes quantity.
+func cpuQuantity(milicores int64) string {
+	if milicores%1000 == 0 {
+		return fmt.Sprintf("%d", milicores/1000)
+	}
+	return fmt.Sprintf("%dm", milicores)
+}
+
+// roundedMemory rounds the bytes up to the mebibyte, as memoryQuantity writes them.
+func roundedMemory(bytes int64) int64 {
+	return (bytes + MEGABYTE - 1) / MEGABYTE * MEGABYTE
+}
+
+// memoryQuantity formats the bytes as a Kubernetes quantity, rounded up to the mebibyte.
+func memoryQuantity(bytes int64) string {
+	mebibytes := roundedMemory(bytes) / MEGABYTE
+	if mebibytes%1024 == 0 {
+		return fmt.Sprintf("%dGi", mebibytes/1024)
+	}
+	return fmt.Sprintf("%dMi", mebibytes)
+}
+
+// limitRangeDocument returns the LimitRange setting the default requests and limits of the
+// containers of the namespace.
+func limitRangeDocument(namespace string, cpu int64, memory int64, cpuLimit int64, memoryLimit int64) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "LimitRange",
+		"metadata": map[string]interface{}{
+			"name":      "kapct-defaults",
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"limits": []interface{}{
+				map[string]interface{}{
+					"type":           "Container",
+					"defaultRequest": map[string]string{"cpu": cpuQuantity(cpu), "memory": memoryQuantity(memory)},
+					"default":        map[string]string{"cpu": cpuQuantity(cpuLimit), "memory": memoryQuantity(memoryLimit)},
+				},
+			},
+		},
+	}
+}
+
+// patchableKinds are the workload kinds with a pod template the container requests are patched
+// into, the rest e.g. bare pods can not take new requests.
+var patchableKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"CronJob":     true,
+}
+
+// addContainerRequests adds the requests of the container to the pod template in the strategic
+// merge patch of the workload, a CronJob keeps its pod template in its job template.
+func addContainerRequests(patch map[string]interface{}, kind string, container string, requests map[string]string) {
+	path := []string{"spec", "template", "spec"}
+	if kind == "CronJob" {
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+
+	spec := patch
+	for _, field := range path {
+		next, ok := spec[field].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			spec[field] = next
+		}
+		spec = next
+	}
+
+	containers, _ := spec["containers"].([]interface{})
+	spec["containers"] = append(containers, map[string]interface{}{
+		"name":      container,
+		"resources": map[string]interface{}{"requests": requests},
+	})
+}
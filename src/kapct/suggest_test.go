@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestSuggestedRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []int64
+		percentile float64
+		fallback   int64
+		least      int64
+		request    int64
+	}{
+		{name: "no samples", samples: nil, percentile: 90, fallback: 100, least: 10, request: 100},
+		{name: "nearest rank", samples: []int64{500, 100, 300, 200, 400}, percentile: 50, fallback: 100, least: 10, request: 300},
+		{name: "top percentile", samples: []int64{500, 100, 300, 200, 400}, percentile: 100, fallback: 100, least: 10, request: 500},
+		{name: "low percentile takes the smallest", samples: []int64{500, 100, 300}, percentile: 1, fallback: 100, least: 10, request: 100},
+		{name: "no less than the least", samples: []int64{2, 3}, percentile: 90, fallback: 100, least: 10, request: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if request := suggestedRequest(tt.samples, tt.percentile, tt.fallback, tt.least); request != tt.request {
+				t.Errorf("suggestedRequest = %d, want %d", request, tt.request)
+			}
+		})
+	}
+}
+
+func TestDefaultLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    []int64
+		unlimited []int64
+		limit     int64
+	}{
+		{name: "ratio times the request without limits", limit: 400},
+		{name: "percentile of the limits", limits: []int64{300, 600, 900}, limit: 900},
+		{name: "no less than the request", limits: []int64{100}, limit: 200},
+		{name: "no less than the requests of the containers without limits", limits: []int64{300, 600}, unlimited: []int64{250, 1500}, limit: 1500},
+		{name: "requests of the containers without limits below it", unlimited: []int64{250}, limit: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if limit := defaultLimit(tt.limits, tt.unlimited, 90, 200, 2); limit != tt.limit {
+				t.Errorf("defaultLimit = %d, want %d", limit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestQuantities(t *testing.T) {
+	cpus := map[int64]string{1000: "1", 2500: "2500m", 10: "10m", 4000: "4"}
+	for milicores, want := range cpus {
+		if quantity := cpuQuantity(milicores); quantity != want {
+			t.Errorf("cpuQuantity(%d) = %s, want %s", milicores, quantity, want)
+		}
+	}
+	memories := map[int64]string{GIGABYTE: "1Gi", 128 * MEGABYTE: "128Mi", 128*MEGABYTE + 1: "129Mi", 3 * GIGABYTE: "3Gi"}
+	for bytes, want := range memories {
+		if quantity := memoryQuantity(bytes); quantity != want {
+			t.Errorf("memoryQuantity(%d) = %s, want %s", bytes, quantity, want)
+		}
+	}
+}
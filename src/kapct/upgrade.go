@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// upgradeStep holds what happened to the pods while a batch of nodes was being replaced.
+type upgradeStep struct {
+	nodes         []string
+	displaced     int
+	unschedulable []string
+}
+
+// upgradeSim simulates a rolling upgrade of the worker nodes. The nodes are replaced
+// in batches of max-unavailable, in the order they are listed, and the pods of a batch
+// are rescheduled on the rest of the nodes, along with the surge nodes if any.
+func upgradeSim(args []string) {
+	var maxUnavailable int
+	var surge int
+	var templateFile string
+
+	f := flag.NewFlagSet("upgrade-sim", flag.ExitOnError)
+	kubeconfig := kubeConfigFlag(f)
+	f.IntVar(&maxUnavailable, "max-unavailable", 1, "number of nodes replaced at a time.")
+	f.IntVar(&surge, "surge", 0, "number of surge nodes added for the upgrade.")
+	f.StringVar(&templateFile, "template", "", "(optional) node definition file to create the surge nodes from, the first worker node is cloned otherwise.")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: kapct upgrade-sim [options]\n\noptions:\n")
+		f.PrintDefaults()
+	}
+	f.Parse(args)
+
+	if maxUnavailable < 1 {
+		fmt.Println("There is a problem with max-unavailable, it must be at least 1!!")
+		os.Exit(2)
+	}
+
+	w := newTabWriter()
+	c := newClientSet(*kubeconfig)
+	workers := getWorkerNodes(c)
+
+	if len(workers) == 0 {
+		fmt.Println(" W: Number of worker nodes are 0!!!")
+		os.Exit(1)
+	}
+
+	// surge nodes join before the first batch goes and stay till the last one is back.
+	template := workers[0].node
+	if templateFile != "" {
+		node, err := readNodeTemplate(templateFile)
+		if err != nil {
+			fmt.Println("There is a problem reading node template!!")
+			panic(err.Error())
+		}
+		template = node
+	}
+	// they start the pods of the DaemonSets matching them before taking any of the displaced ones.
+	daemonSets := make([]appsv1.DaemonSet, 0)
+	if surge > 0 {
+		daemonSets = getDaemonSets(c)
+	}
+	nodes, dsCPU, dsMemory, dsPods := append([]*clusterNode{}, workers...), int64(0), int64(0), 0
+	for i := 1; i <= surge; i++ {
+		n := cloneNode(template, fmt.Sprintf("surge-%d", i), daemonSets)
+		dsCPU, dsMemory, _ = daemonSetTax(n.pods)
+		dsPods = len(n.pods)
+		nodes = append(nodes, n)
+	}
+
+	steps := make([]upgradeStep, 0, len(workers)/maxUnavailable+1)
+	pending := make([]podMove, 0, 3)
+	for start := 0; start < len(workers); start += maxUnavailable {
+		end := start + maxUnavailable
+		if end > len(workers) {
+			end = len(workers)
+		}
+
+		step := upgradeStep{nodes: make([]string, 0, maxUnavailable)}
+		displaced := pending
+		for _, n := range workers[start:end] {
+			step.nodes = append(step.nodes, n.name)
+			n.node.Spec.Unschedulable = true
+			for _, p := range n.pods {
+				if !isDaemonSetPod(&p) && !isMirrorPod(&p) {
+					displaced = append(displaced, podMove{pod: p, from: n.name})
+				}
+			}
+		}
+		step.displaced = len(displaced) - len(pending)
+
+		// pods left pending by the earlier batches get another try along with the displaced ones.
+		pending = make([]podMove, 0, 3)
+		for _, move := range reschedulePods(displaced, nodes) {
+			if move.to == "" {
+				pending = append(pending, move)
+				step.unschedulable = append(step.unschedulable, fmt.Sprintf("%s (%s)", podKey(&move.pod), move.from))
+			}
+		}
+
+		// the replaced nodes come back upgraded, running only their DaemonSet pods.
+		for _, n := range workers[start:end] {
+			daemons := make([]v1.Pod, 0, len(n.pods))
+			for _, p := range n.pods {
+				if isDaemonSetPod(&p) || isMirrorPod(&p) {
+					daemons = append(daemons, p)
+				}
+			}
+			n.node.Spec.Unschedulable = false
+			*n = *newClusterNode(n.node, daemons)
+		}
+		steps = append(steps, step)
+	}
+
+	printUpgradeSteps(w, steps, surge, dsCPU, dsMemory, dsPods)
+}
+
+// printUpgradeSteps prints the pods displaced and left unschedulable at each step of the upgrade,
+// along with what the DaemonSets take on each surge node.
+func printUpgradeSteps(w *tabwriter.Writer, steps []upgradeStep, surge int, dsCPU int64, dsMemory int64, dsPods int) {
+	Columns(w, "\n")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%40s\t\n", "Upgrade Simulation")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+	Rows(w, "%-5s\t%-11s\t%-5s\t%-5s\t\n", "Step", "Nodes", "Displaced", "Unschedulable")
+	Rows(w, "%-2s\n", "  +-------------------------------------------------------------------------------------------------+")
+
+	firstFailing := 0
+	for i, step := range steps {
+		Rows(w, "%4d\t%s\t%9d\t%13d\t\n", i+1, fmt.Sprint(step.nodes), step.displaced, len(step.unschedulable))
+		if firstFailing == 0 && len(step.unschedulable) > 0 {
+			firstFailing = i + 1
+		}
+	}
+	Columns(w, "\n")
+
+	Rows(w, "%s\t%d\t%s\t%d\n", "Upgrade Steps: ", len(steps), "Surge Nodes: ", surge)
+	if surge > 0 {
+		Rows(w, "%s\t%s\t\n", "DaemonSet Tax Per Surge Node: ", fmt.Sprintf("%dm CPU, %s Memory, %d Pods", dsCPU, FromBytes(dsMemory), dsPods))
+	}
+	if firstFailing == 0 {
+		Rows(w, "%s\t%s\t%s\t%s\n", "First Failing Step: ", "None", "Is Upgrade Safe?: ", "True")
+	} else {
+		Rows(w, "%s\t%d\t%s\t%s\n", "First Failing Step: ", firstFailing, "Is Upgrade Safe?: ", "False")
+		Rows(w, "%s\t%s\t", "Unschedulable Pods List: ", VPrint(steps[firstFailing-1].unschedulable))
+	}
+	Columns(w, "\n")
+	w.Flush()
+}
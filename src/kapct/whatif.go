@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// nodeAddition is a -add-node switch, a node definition file and how many nodes to add from it.
+type nodeAddition struct {
+	path  string
+	count int
+}
+
+// nodeAdditions holds the nodes to add, it implements the flag.Value interface.
+type nodeAdditions []nodeAddition
+
+// nodeRemovals holds the names of the nodes to remove, it implements the flag.Value interface.
+type nodeRemovals []string
+
+// nodeResizes holds the new capacity of the nodes to resize, it implements the flag.Value interface.
+type nodeResizes map[string]v1.ResourceList
+
+// nodeEdits holds the what-if changes made to the nodes before their capacity is calculated.
+type nodeEdits struct {
+	add    nodeAdditions
+	remove nodeRemovals
+	resize nodeResizes
+}
+
+func (a *nodeAdditions) String() string {
+	if a == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*a))
+	for _, addition := range *a {
+		parts = append(parts, fmt.Sprintf("%s:%d", addition.path, addition.count))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a path:count pair, a single node is added when the count is left out.
+func (a *nodeAdditions) Set(value string) error {
+	addition := nodeAddition{path: value, count: 1}
+	if i := strings.LastIndex(value, ":"); i > 0 {
+		count, err := strconv.Atoi(value[i+1:])
+		if err != nil || count < 1 {
+			return fmt.Errorf("%q is not a valid node count, use template.yaml:count", value[i+1:])
+		}
+		addition.path, addition.count = value[:i], count
+	}
+	*a = append(*a, addition)
+	return nil
+}
+
+func (r *nodeRemovals) String() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(*r, ",")
+}
+
+// Set adds a node to the ones to remove.
+func (r *nodeRemovals) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("node name is empty")
+	}
+	*r = append(*r, value)
+	return nil
+}
+
+func (r nodeResizes) String() string {
+	parts := make([]string, 0, len(r))
+	for name, resources := range r {
+		quantities := make([]string, 0, len(resources))
+		for resourceName, quantity := range resources {
+			quantities = append(quantities, fmt.Sprintf("%s:%s", resourceName, quantity.String()))
+		}
+		parts = append(parts, name+"="+strings.Join(quantities, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set parses a node=resource:quantity,... switch e.g. worker-2=cpu:16,memory:64Gi.
+func (r nodeResizes) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("%q is not in node=resource:quantity format", value)
+	}
+
+	resources := make(v1.ResourceList)
+	for _, pair := range strings.Split(parts[1], ",") {
+		fields := strings.SplitN(pair, ":", 2)
+		if len(fields) != 2 || fields[0] == "" {
+			return fmt.Errorf("%q is not in resource:quantity format", pair)
+		}
+		quantity, err := resource.ParseQuantity(fields[1])
+		if err != nil {
+			return fmt.Errorf("%q is not a valid quantity: %v", fields[1], err)
+		}
+		resources[v1.ResourceName(fields[0])] = quantity
+	}
+	r[parts[0]] = resources
+	return nil
+}
+
+// empty tells if there is no change to make to the nodes.
+func (e nodeEdits) empty() bool {
+	return len(e.add) == 0 && len(e.remove) == 0 && len(e.resize) == 0
+}
+
+// nodeNamed tells if the node goes by the name given, in full or short.
+func nodeNamed(node *v1.Node, name string) bool {
+	return node.Name == name || shortName(node.Name) == name || shortName(node.Name) == name+"."
+}
+
+// applyNodeEdits makes the what-if changes on the node list, without touching the cluster.
+// Removed nodes are dropped, resized nodes keep what the kubelet reserves out of the new
+// capacity, and added nodes join as healthy workers. It returns the nodes, the removed ones
+// along with the names of the simulated ones, added or resized, and what was done to them.
+func applyNodeEdits(nodes []v1.Node, edits nodeEdits) ([]v1.Node, []v1.Node, map[string]string) {
+	simulated := make(map[string]string)
+
+	kept, removedNodes := make([]v1.Node, 0, len(nodes)), make([]v1.Node, 0, len(edits.remove))
+	for _, node := range nodes {
+		removed := false
+		for _, name := range edits.remove {
+			if nodeNamed(&node, name) {
+				removed = true
+			}
+		}
+		if removed {
+			removedNodes = append(removedNodes, node)
+		} else {
+			kept = append(kept, node)
+		}
+	}
+	for _, name := range edits.remove {
+		if !containsNode(nodes, name) {
+			fmt.Printf(" W: node %s to remove was not found!!!\n", name)
+		}
+	}
+
+	for name, resources := range edits.resize {
+		found := false
+		for i := range kept {
+			if !nodeNamed(&kept[i], name) {
+				continue
+			}
+			found = true
+			resizeNode(&kept[i], resources)
+			simulated[kept[i].Name] = "resized"
+		}
+		if !found {
+			fmt.Printf(" W: node %s to resize was not found!!!\n", name)
+		}
+	}
+
+	// the counter is shared by all the additions, the names stay unique whatever the templates are named.
+	added := 0
+	for _, addition := range edits.add {
+		template, err := readNodeTemplate(addition.path)
+		if err != nil {
+			fmt.Println("There is a problem reading node template!!")
+			panic(err.Error())
+		}
+		base := template.Name
+		if base == "" {
+			base = "node"
+		}
+		for i := 1; i <= addition.count; i++ {
+			added++
+			clone := cloneNode(template, fmt.Sprintf("%s-sim-%d", base, added), nil).node
+			if clone.Labels == nil {
+				clone.Labels = make(map[string]string)
+			}
+			clone.Labels["node-role.kubernetes.io/node"] = "true"
+			clone.Status.Conditions = nil
+			kept = append(kept, *clone)
+			simulated[clone.Name] = "added"
+		}
+	}
+
+	return kept, removedNodes, simulated
+}
+
+// rehomePods places the pods of the removed worker nodes on the healthy worker nodes kept, the
+// way a drain would, so their requests still count. It returns the pods of every healthy worker
+// node by node name, the rehomed ones included, and the pods which found no room.
+func rehomePods(c *k8s.Clientset, nodes []v1.Node, removed []v1.Node, simulated map[string]string, daemonSets []appsv1.DaemonSet, namespaceList []string) (map[string][]v1.Pod, []string) {
+	workers := make([]*clusterNode, 0, len(nodes))
+	for i := range nodes {
+		if !isHealthy(&nodes[i]) || !isWorker(&nodes[i]) {
+			continue
+		}
+		var pods []v1.Pod
+		if simulated[nodes[i].Name] == "added" {
+			pods = daemonSetPods(daemonSets, &nodes[i])
+		} else {
+			pods = getNodePods(c, nodes[i].Name, namespaceList)
+		}
+		workers = append(workers, newClusterNode(&nodes[i], pods))
+	}
+
+	evicted := make([]podMove, 0, 3)
+	for i := range removed {
+		if !isHealthy(&removed[i]) || !isWorker(&removed[i]) {
+			continue
+		}
+		for _, p := range getNodePods(c, removed[i].Name, namespaceList) {
+			if !isDaemonSetPod(&p) && !isMirrorPod(&p) {
+				evicted = append(evicted, podMove{pod: p, from: shortName(removed[i].Name)})
+			}
+		}
+	}
+
+	unplaced := make([]string, 0, 3)
+	for _, move := range reschedulePods(evicted, workers) {
+		if move.to == "" {
+			unplaced = append(unplaced, fmt.Sprintf("%s (%s)", podKey(&move.pod), move.from))
+		}
+	}
+
+	podsOf := make(map[string][]v1.Pod, len(workers))
+	for _, n := range workers {
+		podsOf[n.node.Name] = n.pods
+	}
+	return podsOf, unplaced
+}
+
+// containsNode tells if there is a node by the name in the list.
+func containsNode(nodes []v1.Node, name string) bool {
+	for i := range nodes {
+		if nodeNamed(&nodes[i], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resizeNode sets the new capacity of the node, the allocatable follows it and keeps the part reserved.
+func resizeNode(node *v1.Node, resources v1.ResourceList) {
+	capacity, allocatable := node.Status.Capacity.DeepCopy(), node.Status.Allocatable.DeepCopy()
+	if capacity == nil {
+		capacity = make(v1.ResourceList)
+	}
+	if allocatable == nil {
+		allocatable = make(v1.ResourceList)
+	}
+	for name, quantity := range resources {
+		reserved := capacity[name]
+		reserved.Sub(allocatable[name])
+		if reserved.Sign() < 0 {
+			reserved = resource.Quantity{}
+		}
+
+		newAllocatable := quantity.DeepCopy()
+		newAllocatable.Sub(reserved)
+		if newAllocatable.Sign() < 0 {
+			newAllocatable = resource.Quantity{}
+		}
+		capacity[name], allocatable[name] = quantity, newAllocatable
+	}
+	node.Status.Capacity, node.Status.Allocatable = capacity, allocatable
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// sizedNode returns a node of the capacity given, the kubelet reserving the part given out of it.
+func sizedNode(name string, capacity v1.ResourceList, reserved v1.ResourceList) v1.Node {
+	node := testNode(name, "0", "0")
+	node.Status.Capacity, node.Status.Allocatable = capacity, capacity.DeepCopy()
+	for resourceName, quantity := range reserved {
+		allocatable := node.Status.Allocatable[resourceName]
+		allocatable.Sub(quantity)
+		node.Status.Allocatable[resourceName] = allocatable
+	}
+	return *node
+}
+
+func TestResizeNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		node        v1.Node
+		resize      v1.ResourceList
+		capacity    v1.ResourceList
+		allocatable v1.ResourceList
+	}{
+		{
+			name:        "keeps the reserved part",
+			node:        sizedNode("worker-1", resources("4", "8Gi"), resources("500m", "1Gi")),
+			resize:      resources("16", "64Gi"),
+			capacity:    resources("16", "64Gi"),
+			allocatable: resources("15500m", "63Gi"),
+		},
+		{
+			name:        "resources left out stay",
+			node:        sizedNode("worker-1", resources("4", "8Gi"), resources("500m", "1Gi")),
+			resize:      v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+			capacity:    resources("8", "8Gi"),
+			allocatable: resources("7500m", "7Gi"),
+		},
+		{
+			name:        "no less than nothing allocatable",
+			node:        sizedNode("worker-1", resources("4", "8Gi"), resources("1", "1Gi")),
+			resize:      v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+			capacity:    resources("500m", "8Gi"),
+			allocatable: v1.ResourceList{v1.ResourceCPU: resource.Quantity{}, v1.ResourceMemory: resource.MustParse("7Gi")},
+		},
+		{
+			name:        "node without capacity",
+			node:        *testNode("worker-1", "4", "8Gi"),
+			resize:      v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+			capacity:    v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+			allocatable: resources("8", "8Gi"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := tt.node.DeepCopy()
+			resizeNode(node, tt.resize)
+			for resourceName, want := range tt.capacity {
+				if got := node.Status.Capacity[resourceName]; got.Cmp(want) != 0 {
+					t.Errorf("capacity %s = %s, want %s", resourceName, got.String(), want.String())
+				}
+			}
+			for resourceName, want := range tt.allocatable {
+				if got := node.Status.Allocatable[resourceName]; got.Cmp(want) != 0 {
+					t.Errorf("allocatable %s = %s, want %s", resourceName, got.String(), want.String())
+				}
+			}
+		})
+	}
+}
+
+func TestApplyNodeEdits(t *testing.T) {
+	template, err := ioutil.TempFile("", "node-*.yaml")
+	if err != nil {
+		t.Fatalf("creating node template: %v", err)
+	}
+	defer os.Remove(template.Name())
+	definition := "apiVersion: v1\nkind: Node\nmetadata:\n  name: large\nstatus:\n  allocatable:\n    cpu: \"16\"\n    memory: 64Gi\n"
+	if _, err := template.WriteString(definition); err != nil {
+		t.Fatalf("writing node template: %v", err)
+	}
+	template.Close()
+
+	nodes := []v1.Node{
+		sizedNode("worker-1.example.com", resources("4", "8Gi"), resources("500m", "1Gi")),
+		sizedNode("worker-2.example.com", resources("4", "8Gi"), resources("500m", "1Gi")),
+		sizedNode("worker-3.example.com", resources("4", "8Gi"), resources("500m", "1Gi")),
+	}
+
+	tests := []struct {
+		name      string
+		edits     nodeEdits
+		kept      []string
+		removed   []string
+		simulated map[string]string
+	}{
+		{
+			name:      "no edits",
+			kept:      []string{"worker-1.example.com", "worker-2.example.com", "worker-3.example.com"},
+			removed:   []string{},
+			simulated: map[string]string{},
+		},
+		{
+			name:      "remove by short and full name",
+			edits:     nodeEdits{remove: nodeRemovals{"worker-1", "worker-3.example.com", "worker-9"}},
+			kept:      []string{"worker-2.example.com"},
+			removed:   []string{"worker-1.example.com", "worker-3.example.com"},
+			simulated: map[string]string{},
+		},
+		{
+			name:      "resize",
+			edits:     nodeEdits{resize: nodeResizes{"worker-2": resources("16", "64Gi")}},
+			kept:      []string{"worker-1.example.com", "worker-2.example.com", "worker-3.example.com"},
+			removed:   []string{},
+			simulated: map[string]string{"worker-2.example.com": "resized"},
+		},
+		{
+			name:      "added names stay unique across the additions",
+			edits:     nodeEdits{add: nodeAdditions{{path: template.Name(), count: 2}, {path: template.Name(), count: 1}}, remove: nodeRemovals{"worker-2"}},
+			kept:      []string{"worker-1.example.com", "worker-3.example.com", "large-sim-1", "large-sim-2", "large-sim-3"},
+			removed:   []string{"worker-2.example.com"},
+			simulated: map[string]string{"large-sim-1": "added", "large-sim-2": "added", "large-sim-3": "added"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, removed, simulated := applyNodeEdits(nodes, tt.edits)
+			if names := nodeNames(kept); !reflect.DeepEqual(names, tt.kept) {
+				t.Errorf("kept = %v, want %v", names, tt.kept)
+			}
+			if names := nodeNames(removed); !reflect.DeepEqual(names, tt.removed) {
+				t.Errorf("removed = %v, want %v", names, tt.removed)
+			}
+			if !reflect.DeepEqual(simulated, tt.simulated) {
+				t.Errorf("simulated = %v, want %v", simulated, tt.simulated)
+			}
+			for _, node := range kept {
+				if simulated[node.Name] == "added" && node.Labels["node-role.kubernetes.io/node"] != "true" {
+					t.Errorf("added node %s is not labeled a worker", node.Name)
+				}
+			}
+		})
+	}
+
+	// the edits are made on copies, the nodes of the cluster stay as they are.
+	if cpu := nodes[1].Status.Capacity[v1.ResourceCPU]; cpu.Cmp(resource.MustParse("4")) != 0 {
+		t.Errorf("worker-2 capacity cpu = %s after the edits, want 4", cpu.String())
+	}
+}
+
+func nodeNames(nodes []v1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
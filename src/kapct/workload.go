@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// readWorkload reads a workload definition file and returns the template of the pods it creates.
+func readWorkload(path string) (*v1.PodTemplateSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch workload := obj.(type) {
+	case *v1.Pod:
+		return &v1.PodTemplateSpec{ObjectMeta: workload.ObjectMeta, Spec: workload.Spec}, nil
+	case *v1.ReplicationController:
+		if workload.Spec.Template == nil {
+			return nil, fmt.Errorf("replicationcontroller %s has no pod template", workload.Name)
+		}
+		return workload.Spec.Template, nil
+	case *appsv1.Deployment:
+		return &workload.Spec.Template, nil
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template, nil
+	case *appsv1.ReplicaSet:
+		return &workload.Spec.Template, nil
+	case *appsv1.DaemonSet:
+		return &workload.Spec.Template, nil
+	case *batchv1.Job:
+		return &workload.Spec.Template, nil
+	case *batchv1beta1.CronJob:
+		return &workload.Spec.JobTemplate.Spec.Template, nil
+	}
+
+	return nil, fmt.Errorf("%s is not a supported workload kind", obj.GetObjectKind().GroupVersionKind().Kind)
+}
+
+// readNodeTemplate reads a node definition file, used as a template for the nodes added in the simulations.
+func readNodeTemplate(path string) (*v1.Node, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a node definition", path)
+	}
+	return node, nil
+}
+
+// parseReferenceArgs parses the switches on either side of a workload reference,
+// as in deployment/web -n shop, and returns the reference.
+func parseReferenceArgs(f *flag.FlagSet, args []string) string {
+	f.Parse(args)
+	if f.NArg() == 0 {
+		return ""
+	}
+	reference := f.Arg(0)
+	f.Parse(f.Args()[1:])
+	return reference
+}
+
+// parseReference splits a workload reference in kind/name form, e.g. deployment/web,
+// into the kind and the name. The short names kubectl accepts are understood as well.
+func parseReference(reference string) (string, string) {
+	kv := strings.SplitN(reference, "/", 2)
+	if len(kv) != 2 {
+		return "", reference
+	}
+
+	switch strings.ToLower(kv[0]) {
+	case "deploy", "deployment", "deployments":
+		return "deployment", kv[1]
+	case "sts", "statefulset", "statefulsets":
+		return "statefulset", kv[1]
+	case "rs", "replicaset", "replicasets":
+		return "replicaset", kv[1]
+	}
+	return strings.ToLower(kv[0]), kv[1]
+}
+
+// getWorkloadTemplate fetches the live workload and returns the template of its pods
+// along with the number of replicas it currently runs.
+func getWorkloadTemplate(c *k8s.Clientset, kind string, namespace string, name string) (*v1.PodTemplateSpec, int32, error) {
+	switch kind {
+	case "deployment":
+		workload, err := c.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+		return &workload.Spec.Template, workload.Status.Replicas, nil
+	case "statefulset":
+		workload, err := c.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+		return &workload.Spec.Template, workload.Status.Replicas, nil
+	case "replicaset":
+		workload, err := c.AppsV1().ReplicaSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+		return &workload.Spec.Template, workload.Status.Replicas, nil
+	}
+
+	return nil, 0, fmt.Errorf("%s is not a supported workload kind", kind)
+}